@@ -0,0 +1,161 @@
+package commands
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/ainvaltin/nu-plugin"
+
+	"github.com/montanaflynn/stats"
+
+	"github.com/gtnebel/nu_plugin_nuplot/commands/flags"
+)
+
+// List of all available aggregation functions
+var AggregateFuncs = []string{
+	"sum", "mean", "median", "min", "max", "count", "first", "last",
+}
+
+// Numeric aggregation functions by name. The functions count, first and last
+// work on all values and are handled in [aggregateValues].
+var numericAggregateFuncs = map[string]func(stats.Float64Data) (float64, error){
+	"sum":    stats.Sum,
+	"mean":   stats.Mean,
+	"median": stats.Median,
+	"min":    stats.Min,
+	"max":    stats.Max,
+}
+
+// Reads the --agg flag and checks that it names a known aggregation function.
+// An empty string is returned if the flag is not given.
+func getAggregateFlag(call *nu.ExecCommand) (string, error) {
+	agg := getStringFlag(call, flags.Agg.Long, "")
+	if agg != "" && !slices.Contains(AggregateFuncs, agg) {
+		return "", fmt.Errorf("invalid --agg %q, expected one of: %v", agg, AggregateFuncs)
+	}
+
+	return agg, nil
+}
+
+// Collapses a list of values into a single value. Numeric functions ignore
+// values that are not numbers, filesizes, durations or dates. If there are no
+// numbers at all, the first value is kept, so that label columns survive the
+// aggregation.
+func aggregateValues(values []nu.Value, agg string) nu.Value {
+	switch agg {
+	case "first":
+		return values[0]
+	case "last":
+		return values[len(values)-1]
+	case "count":
+		count := 0
+		for _, v := range values {
+			if v.Value != nil {
+				count++
+			}
+		}
+		return nu.Value{Value: int64(count)}
+	}
+
+	// Filesizes, durations and dates keep their kind.
+	data := make(stats.Float64Data, 0, len(values))
+	kind := kindNumber
+	for _, v := range values {
+		if f, k, err := valueToFloat64Kind(v); err == nil {
+			data = append(data, f)
+			kind = k
+		}
+	}
+	if len(data) == 0 {
+		return values[0]
+	}
+
+	res, _ := numericAggregateFuncs[agg](data)
+	return float64ToValue(res, kind)
+}
+
+// Collapses all rows of the input table that share the same x value, if the
+// --agg flag is given. If --group-by is given too, the rows are collapsed per
+// x value and group. The rows keep the order in which their x value first
+// appears. All other inputs are returned unchanged.
+func aggregateRows(input any, call *nu.ExecCommand) (any, error) {
+	agg, err := getAggregateFlag(call)
+	if err != nil || agg == "" {
+		return input, err
+	}
+
+	table, ok := input.([]nu.Value)
+	if !ok || len(table) == 0 {
+		return input, nil
+	}
+
+	first, ok := table[0].Value.(nu.Record)
+	if !ok {
+		// A plain list of numbers has no x values to aggregate on.
+		return input, nil
+	}
+
+	xAxisName := getCellPathFlag(call, flags.XAxis.Long, XAxisSeries)
+	// The slices of a pie chart are named by the --label column.
+	if labelName := getCellPathFlag(call, flags.Label.Long, ""); labelName != "" {
+		xAxisName = labelName
+	}
+	xAxisName = autoSetXaxis(first, xAxisName)
+	if xAxisName == XAxisSeries {
+		return nil, fmt.Errorf("aggregateRows: --agg needs an x-axis column, use --xaxis")
+	}
+	groupName := getCellPathFlag(call, flags.GroupBy.Long, "")
+	slog.Debug("aggregateRows", "agg", agg, "xAxisName", xAxisName, "group", groupName)
+
+	keys := make([]string, 0)
+	rows := make(map[string][]nu.Record)
+
+	for itemIndex, item := range table {
+		record, ok := item.Value.(nu.Record)
+		if !ok {
+			return nil, fmt.Errorf("aggregateRows: unsupported input value type %T in row %d", item.Value, itemIndex)
+		}
+
+		key := fmt.Sprint(record[xAxisName].Value)
+		if groupName != "" {
+			key += "\x00" + fmt.Sprint(record[groupName].Value)
+		}
+
+		if _, ok := rows[key]; !ok {
+			keys = append(keys, key)
+		}
+		rows[key] = append(rows[key], record)
+	}
+
+	res := make([]nu.Value, 0, len(keys))
+	for _, key := range keys {
+		records := rows[key]
+		row := make(nu.Record)
+
+		for _, record := range records {
+			for k := range record {
+				if _, ok := row[k]; ok {
+					continue
+				}
+				if k == xAxisName || k == groupName {
+					row[k] = record[k]
+					continue
+				}
+
+				values := make([]nu.Value, 0, len(records))
+				for _, r := range records {
+					if v, ok := r[k]; ok {
+						values = append(values, v)
+					}
+				}
+				row[k] = aggregateValues(values, agg)
+			}
+		}
+
+		res = append(res, nu.Value{Value: row})
+	}
+	slog.Debug("aggregateRows: Collapsed rows", "rows", len(table), "aggregated", len(res))
+
+	return res, nil
+}
@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ainvaltin/nu-plugin"
+)
+
+// Wraps plain values into nushell values.
+func nuValues(values ...any) []nu.Value {
+	res := make([]nu.Value, len(values))
+	for i, v := range values {
+		res[i] = nu.Value{Value: v}
+	}
+	return res
+}
+
+func TestAggregateValues(t *testing.T) {
+	numbers := nuValues(int64(4), 1.5, nil, int64(2), "n/a")
+
+	tests := []struct {
+		name   string
+		values []nu.Value
+		agg    string
+		want   any
+	}{
+		{"sum", numbers, "sum", 7.5},
+		{"mean", numbers, "mean", 2.5},
+		{"median", numbers, "median", 2.0},
+		{"min", numbers, "min", 1.5},
+		{"max", numbers, "max", 4.0},
+		{"count skips nulls", numbers, "count", int64(4)},
+		{"first", numbers, "first", int64(4)},
+		{"last", numbers, "last", "n/a"},
+		{"labels keep the first value", nuValues("a", "b"), "sum", "a"},
+		{"filesizes", nuValues(nu.Filesize(1024), nu.Filesize(2048)), "sum", nu.Filesize(3072)},
+		{"durations", nuValues(time.Second, 3*time.Second), "mean", 2 * time.Second},
+		{"dates", nuValues(time.UnixMilli(1000), time.UnixMilli(5000)), "max", time.UnixMilli(5000)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := aggregateValues(tt.values, tt.agg).Value
+			if date, ok := tt.want.(time.Time); ok {
+				if gotDate, ok := got.(time.Time); !ok || !gotDate.Equal(date) {
+					t.Errorf("got %v (%T), want %v", got, got, tt.want)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("got %v (%T), want %v (%T)", got, got, tt.want, tt.want)
+			}
+		})
+	}
+}
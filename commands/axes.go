@@ -0,0 +1,255 @@
+package commands
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+
+	"github.com/ainvaltin/nu-plugin"
+
+	"github.com/gtnebel/nu_plugin_nuplot/commands/flags"
+)
+
+// Javascript that sets the base of all logarithmic axes, which is not part
+// of [opts.XAxis] and [opts.YAxis]. It is merged into the options of the
+// rendered chart, so that it works along with other scripts and
+// configuration visitors of the chart.
+const logBaseScript = `(function () {
+	var base = %v;
+	var option = %%MY_ECHARTS%%.getOption();
+	var update = {};
+	['xAxis', 'yAxis'].forEach(function (key) {
+		update[key] = (option[key] || []).map(function (axis) {
+			return axis.type === 'log' ? {logBase: base} : {};
+		});
+	});
+	%%MY_ECHARTS%%.setOption(update);
+})();`
+
+// The settings of an axis given by the flags of a command.
+type axisSettings struct {
+	Name string
+	Min  any
+	Max  any
+	Log  bool
+}
+
+// Applies the settings to an x-axis.
+func (s axisSettings) applyX(xAxis *opts.XAxis) {
+	if s.Name != "" {
+		xAxis.Name = s.Name
+	}
+	if s.Min != nil {
+		xAxis.Min = s.Min
+	}
+	if s.Max != nil {
+		xAxis.Max = s.Max
+	}
+	if s.Log {
+		xAxis.Type = "log"
+	}
+}
+
+// Applies the settings to a y-axis.
+func (s axisSettings) applyY(yAxis *opts.YAxis) {
+	if s.Name != "" {
+		yAxis.Name = s.Name
+	}
+	if s.Min != nil {
+		yAxis.Min = s.Min
+	}
+	if s.Max != nil {
+		yAxis.Max = s.Max
+	}
+	if s.Log {
+		yAxis.Type = "log"
+	}
+}
+
+// Retrieve the float64 value of a number flag from the call. The returned bool
+// is false, if the flag is not given.
+func getFloatFlag(call *nu.ExecCommand, name string) (float64, bool) {
+	value, _ := call.FlagValue(name)
+
+	switch v := value.Value.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// Returns a nushell error that points at the given flag on the command line.
+func flagError(call *nu.ExecCommand, flag nu.Flag, err error) error {
+	value, _ := call.FlagValue(flag.Long)
+	return &nu.Error{
+		Err:    fmt.Errorf("--%s: %w", flag.Long, err),
+		Labels: []nu.Label{{Text: err.Error(), Span: value.Span}},
+	}
+}
+
+// Converts the value of the --xmin or --xmax flag to a bound of the x-axis.
+// Dates are converted to milliseconds since epoch for time axes.
+func xAxisBound(value string) any {
+	switch v := matchXValue(nu.Value{Value: value}).(type) {
+	case time.Time:
+		return v.UnixMilli()
+	default:
+		return v
+	}
+}
+
+// Builds the options for the names and ranges of the axes and the logarithmic
+// y-axis. The logarithmic x-axis needs numeric x positions and is set up by
+// the chart commands themselves, see [withLogXAxis]. The flags of the y-axis
+// always refer to the value axis, which is the x-axis of reversed bars.
+func buildAxisOptions(call *nu.ExecCommand) ([]charts.GlobalOpts, error) {
+	base := 10.0
+	if b, ok := getFloatFlag(call, flags.LogBase.Long); ok {
+		base = b
+	}
+	if base <= 1 {
+		return nil, flagError(call, flags.LogBase, fmt.Errorf("the base has to be greater than 1, got %v", base))
+	}
+
+	category := axisSettings{Name: getStringFlag(call, flags.XName.Long, "")}
+	if xMin := getStringFlag(call, flags.XMin.Long, ""); xMin != "" {
+		category.Min = xAxisBound(xMin)
+	}
+	if xMax := getStringFlag(call, flags.XMax.Long, ""); xMax != "" {
+		category.Max = xAxisBound(xMax)
+	}
+
+	value := axisSettings{
+		Name: getStringFlag(call, flags.YName.Long, ""),
+		Log:  getBoolFlag(call, flags.YLog.Long),
+	}
+	if yMin, ok := getFloatFlag(call, flags.YMin.Long); ok {
+		if value.Log && yMin <= 0 {
+			return nil, flagError(call, flags.YMin, fmt.Errorf("a logarithmic axis can not start at %v", yMin))
+		}
+		value.Min = yMin
+	}
+	if yMax, ok := getFloatFlag(call, flags.YMax.Long); ok {
+		value.Max = yMax
+	}
+
+	return axisOptions(category, value, getBoolFlag(call, flags.XYReverse.Long), base), nil
+}
+
+// Returns the options that apply the settings of the category and the value
+// axis to the chart. The value axis is the x-axis, if the axes of the chart
+// are reversed. The base of logarithmic axes is set by [logBaseScript].
+func axisOptions(category, value axisSettings, reversed bool, base float64) []charts.GlobalOpts {
+	xAxis, yAxis := category, value
+	if reversed {
+		xAxis, yAxis = value, category
+	}
+
+	res := []charts.GlobalOpts{
+		withXAxisChange(xAxis.applyX),
+		withYAxisChange(yAxis.applyY),
+	}
+	// echarts uses base 10 by default.
+	if base != 10 {
+		res = append(res, func(bc *charts.BaseConfiguration) {
+			bc.AddJSFuncs(fmt.Sprintf(logBaseScript, base))
+		})
+	}
+	return res
+}
+
+// Returns a global option that turns the x-axis into a logarithmic axis. The
+// series data has to hold the positions of the data points, see
+// [float64ToLineDataAt].
+func withLogXAxis() charts.GlobalOpts {
+	return withXAxisChange(func(xAxis *opts.XAxis) {
+		xAxis.Type = "log"
+	})
+}
+
+// Checks that all values of a logarithmic axis are positive. The error points
+// at the flag that enabled the logarithmic axis.
+func checkLogAxisValues(call *nu.ExecCommand, flag nu.Flag, columns ...[]float64) error {
+	if !getBoolFlag(call, flag.Long) {
+		return nil
+	}
+
+	if err := checkPositiveValues(columns...); err != nil {
+		return flagError(call, flag, err)
+	}
+	return nil
+}
+
+// Checks that all values can be shown on a logarithmic axis. Missing values
+// are ignored.
+func checkPositiveValues(columns ...[]float64) error {
+	for _, column := range columns {
+		for _, v := range column {
+			if !math.IsNaN(v) && v <= 0 {
+				return fmt.Errorf("a logarithmic axis can only show positive values, found %v", v)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Returns the positions of the rows on a numeric axis, if all x values are
+// numbers. Otherwise nil is returned.
+func (t *numericTable) numericPositions() []float64 {
+	if t.XAxisName == XAxisSeries || t.X.len() == 0 {
+		return nil
+	}
+	if t.X.Kind == xNumbers {
+		return slices.Clone(t.X.Numbers)
+	}
+
+	positions := make([]float64, t.X.len())
+	for i, x := range t.X.values() {
+		v, err := ValueToFloat64(nu.Value{Value: x})
+		if err != nil {
+			return nil
+		}
+		positions[i] = v
+	}
+
+	return positions
+}
+
+// Chooses the type of the x-axis for the table and returns the positions of
+// the data points along with the options for the axis. Dates are plotted on a
+// time axis and numbers on a logarithmic axis, if --xlog is given. For all
+// other x values nil is returned and the x values are plotted as categories.
+func (t *numericTable) xAxisOptions(call *nu.ExecCommand) ([]float64, []charts.GlobalOpts, error) {
+	xLog := getBoolFlag(call, flags.XLog.Long)
+
+	if positions := t.timePositions(); positions != nil {
+		if xLog {
+			return nil, nil, flagError(call, flags.XLog, fmt.Errorf("dates can not be plotted on a logarithmic axis"))
+		}
+		return positions, []charts.GlobalOpts{withTimeXAxis(call)}, nil
+	}
+
+	if !xLog {
+		return nil, nil, nil
+	}
+
+	positions := t.numericPositions()
+	if positions == nil {
+		return nil, nil, flagError(call, flags.XLog, fmt.Errorf("a logarithmic axis needs numeric x values"))
+	}
+	if err := checkLogAxisValues(call, flags.XLog, positions); err != nil {
+		return nil, nil, err
+	}
+
+	return positions, []charts.GlobalOpts{withLogXAxis()}, nil
+}
@@ -0,0 +1,211 @@
+package commands
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+	"github.com/go-echarts/go-echarts/v2/types"
+
+	"github.com/ainvaltin/nu-plugin"
+
+	"github.com/gtnebel/nu_plugin_nuplot/commands/flags"
+)
+
+func TestCheckPositiveValues(t *testing.T) {
+	nan := math.NaN()
+
+	tests := []struct {
+		name    string
+		columns [][]float64
+		wantErr bool
+	}{
+		{"no columns", nil, false},
+		{"positive values", [][]float64{{1, 0.001}, {1e9}}, false},
+		{"missing values are ignored", [][]float64{{nan, 2, nan}}, false},
+		{"zero", [][]float64{{1, 0}}, true},
+		{"negative value in second column", [][]float64{{1}, {2, -0.5}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkPositiveValues(tt.columns...); (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckLogAxisValuesWithoutFlag(t *testing.T) {
+	if err := checkLogAxisValues(&nu.ExecCommand{}, flags.YLog, []float64{-1, 0}); err != nil {
+		t.Errorf("got error %v without the flag, want none", err)
+	}
+}
+
+func TestAxisOptions(t *testing.T) {
+	category := axisSettings{Name: "day", Min: "mon", Max: "fri"}
+	value := axisSettings{Name: "load", Min: 1.0, Max: 100.0, Log: true}
+
+	tests := []struct {
+		name     string
+		reversed bool
+		x        axisSettings
+		y        axisSettings
+	}{
+		{"value axis on y", false, category, value},
+		{"value axis on x of reversed bars", true, value, category},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bar := charts.NewBar()
+			bar.SetGlobalOptions(axisOptions(category, value, tt.reversed, 10)...)
+
+			xAxis, yAxis := bar.XAxisList[0], bar.YAxisList[0]
+			if xAxis.Name != tt.x.Name || xAxis.Min != tt.x.Min || xAxis.Max != tt.x.Max || (xAxis.Type == "log") != tt.x.Log {
+				t.Errorf("got x-axis %+v, want %+v", xAxis, tt.x)
+			}
+			if yAxis.Name != tt.y.Name || yAxis.Min != tt.y.Min || yAxis.Max != tt.y.Max || (yAxis.Type == "log") != tt.y.Log {
+				t.Errorf("got y-axis %+v, want %+v", yAxis, tt.y)
+			}
+		})
+	}
+}
+
+// A configuration visitor that replaces the title, to detect if the visitor
+// of a chart is kept.
+type titleVisitor struct {
+	charts.BaseConfigurationVisitor
+}
+
+func (titleVisitor) VisitTitleOpt(opts.Title) interface{} {
+	return "visited"
+}
+
+func TestAxisOptionsLogBase(t *testing.T) {
+	tests := []struct {
+		name       string
+		base       float64
+		wantScript bool
+	}{
+		{"default base", 10, false},
+		{"base 2", 2, true},
+		{"base e", math.E, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := charts.NewLine()
+			line.Accept(titleVisitor{})
+			line.SetGlobalOptions(axisOptions(axisSettings{}, axisSettings{Log: true}, false, tt.base)...)
+
+			scripts := slices.IndexFunc(line.JSFunctions.Fns, func(fn types.FuncStr) bool {
+				return strings.Contains(string(fn), "logBase")
+			})
+			if (scripts >= 0) != tt.wantScript {
+				t.Errorf("got log base script %v, want %v", scripts >= 0, tt.wantScript)
+			}
+			if tt.wantScript && !strings.Contains(string(line.JSFunctions.Fns[scripts]), "var base = "+fmt.Sprint(tt.base)) {
+				t.Errorf("got script %q, want base %v", line.JSFunctions.Fns[scripts], tt.base)
+			}
+			if got := line.JSON()["title"]; got != "visited" {
+				t.Errorf("got title %v, want the configuration visitor to be kept", got)
+			}
+		})
+	}
+}
+
+func TestXAxisOptionsWithoutFlags(t *testing.T) {
+	start := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		input         []nu.Value
+		xAxisName     string
+		wantPositions []float64
+	}{
+		{
+			name: "dates on a time axis",
+			input: records(
+				map[string]any{"t": start, "y": int64(1)},
+				map[string]any{"t": start.Add(time.Hour), "y": int64(2)},
+			),
+			xAxisName:     "t",
+			wantPositions: []float64{float64(start.UnixMilli()), float64(start.Add(time.Hour).UnixMilli())},
+		},
+		{
+			name: "numbers as categories",
+			input: records(
+				map[string]any{"n": int64(10), "y": int64(1)},
+				map[string]any{"n": int64(100), "y": int64(2)},
+			),
+			xAxisName: "n",
+		},
+		{
+			name:      "row index",
+			input:     nuValues(int64(1), int64(2)),
+			xAxisName: XAxisSeries,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, err := readNumericTable(tt.input, tt.xAxisName, time.UTC, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			positions, xAxisOpts, err := table.xAxisOptions(&nu.ExecCommand{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(positions, tt.wantPositions) {
+				t.Errorf("got positions %v, want %v", positions, tt.wantPositions)
+			}
+			if (len(xAxisOpts) > 0) != (tt.wantPositions != nil) {
+				t.Errorf("got %d axis options for positions %v", len(xAxisOpts), positions)
+			}
+		})
+	}
+}
+
+func TestNumericPositions(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []nu.Value
+		want  []float64
+	}{
+		{
+			name: "numbers",
+			input: records(
+				map[string]any{"x": int64(3), "y": int64(1)},
+				map[string]any{"x": 0.5, "y": int64(2)},
+			),
+			want: []float64{3, 0.5},
+		},
+		{
+			name: "strings",
+			input: records(
+				map[string]any{"x": "a", "y": int64(1)},
+				map[string]any{"x": "b", "y": int64(2)},
+			),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, err := readNumericTable(tt.input, "x", time.UTC, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := table.numericPositions(); !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -33,8 +33,30 @@ func NuplotBar() *nu.Command {
 			// OptionalPositional: nu.PositionalArgs{},
 			Named: []nu.Flag{
 				flags.XAxis,
+				flags.GroupBy,
+				flags.Y,
+				flags.Exclude,
+				flags.Agg,
+				flags.Missing,
+				flags.Transform,
+				flags.XFormat,
+				flags.Timezone,
+				flags.Facet,
+				flags.FacetScales,
+				flags.FacetColumns,
 				flags.XYReverse,
 				flags.Stacked,
+				flags.XName,
+				flags.YName,
+				flags.XMin,
+				flags.XMax,
+				flags.YMin,
+				flags.YMax,
+				flags.XLog,
+				flags.YLog,
+				flags.LogBase,
+				flags.MaxPoints,
+				flags.Compact,
 				flags.Title,
 				flags.SubTitle,
 				flags.Width,
@@ -44,6 +66,9 @@ func NuplotBar() *nu.Command {
 			},
 			InputOutputTypes: []nu.InOutTypes{
 				{In: types.Table(types.RecordDef{}), Out: types.Nothing()},
+				{In: types.Record(types.RecordDef{}), Out: types.Nothing()},
+				{In: types.String(), Out: types.Nothing()},
+				{In: types.Binary(), Out: types.Nothing()},
 				// {In: types.List(types.Table(types.RecordDef{})), Out: types.Nothing()},
 				{In: types.List(types.Number()), Out: types.Nothing()},
 			},
@@ -55,6 +80,30 @@ func NuplotBar() *nu.Command {
 				Example:     `[5, 4, 3, 2, 5, 7, 8] | nuplot bar`,
 				// Result:      &nu.Value{Value: []nu.Value{{Value: 10}, {Value: "foo"}}},
 			},
+			{
+				Description: `Plot two series from a record of lists.`,
+				Example:     `{a: [1 2 3], b: [4 5 6]} | nuplot bar`,
+			},
+			{
+				Description: `Plot the sales per region and quarter from a table in long format.`,
+				Example:     `[[quarter region sales]; [Q1 north 10] [Q1 south 7] [Q2 north 12] [Q2 south 9]] | nuplot bar --xaxis quarter --group-by region --y sales`,
+			},
+			{
+				Description: `Plot only some columns of a table in a fixed order.`,
+				Example:     `[[id month costs revenue profit]; [1 Jan 10 15 5] [2 Feb 12 14 2]] | nuplot bar --xaxis month --y revenue,costs`,
+			},
+			{
+				Description: `Plot the revenue of each month indexed to 100 at the first month.`,
+				Example:     `[[month revenue]; [Jan 80] [Feb 92] [Mar 104]] | nuplot bar --xaxis month --transform index100`,
+			},
+			{
+				Description: `Plot the sizes of the files in the current directory.`,
+				Example:     `ls | nuplot bar --xaxis name --y size`,
+			},
+			{
+				Description: `Plot the peaks of a long series with at most 500 bars.`,
+				Example:     `1..100000 | each {|i| $i mod 997 } | nuplot bar --max-points 500`,
+			},
 		},
 		OnRun: nuplotBarHandler,
 	}
@@ -62,98 +111,91 @@ func NuplotBar() *nu.Command {
 
 func nuplotBarHandler(ctx context.Context, call *nu.ExecCommand) error {
 	checkVerboseFlag(call)
-	return handleCommandInput(call, plotBar)
+	return handleTableInput(call, withFacets(plotBar, buildBar))
 }
 
-func plotBar(input any, call *nu.ExecCommand) error {
-	series := make(BarDataSeries)
-
-	xAxisName := getCellPathFlag(call, "xaxis", XAxisSeries)
-	slog.Debug("plotBar", "xAxisName", xAxisName)
-
-	switch inputValue := input.(type) {
-	case []nu.Value:
-		for itemIndex, item := range inputValue {
-			switch itemValue := item.Value.(type) {
-			case int64:
-				items := getSeries(series, DefaultSeries)
-				series[DefaultSeries] = append(items, opts.BarData{Value: itemValue})
-			case float64:
-				items := getSeries(series, DefaultSeries)
-				series[DefaultSeries] = append(items, opts.BarData{Value: itemValue})
-			case nu.Record:
-				// Try to set xAxisName to one of the columns in the record.
-				if itemIndex == 0 {
-					xAxisName = autoSetXaxis(itemValue, xAxisName)
-				}
-
-				for k, v := range itemValue {
-					if k == xAxisName {
-						continue
-					}
-
-					_, ok1 := v.Value.(int64)
-					_, ok2 := v.Value.(float64)
-					if ok1 || ok2 {
-						items := getSeries(series, k)
-						series[k] = append(items, opts.BarData{Value: v.Value})
-					}
-				}
-
-				// If a xaxis is defined, fill the series with the values.
-				if xAxisName != XAxisSeries {
-					if v, ok := itemValue[xAxisName]; ok {
-						items := getSeries(series, xAxisName)
-						series[xAxisName] = append(items, opts.BarData{Value: matchXValue(v)})
-					} else {
-						slog.Warn("Specified x-axis is not continuous. Reseting x-axis to default value.")
-						// If the column specified in --xaxis does not exist, we
-						// set the `xAxisName` variable to XAxisSeries, so that a
-						// simple int range is generated as x axis.
-						xAxisName = XAxisSeries
-					}
-				}
-			default:
-				return fmt.Errorf("plotBar: unsupported input value type: %T", inputValue)
-			}
-		}
-	default:
-		return fmt.Errorf("plotBar: unsupported input value type: %T", inputValue)
+func buildBar(input any, call *nu.ExecCommand) (*charts.Bar, valueRange, error) {
+	table, ok := input.(*numericTable)
+	if !ok {
+		return nil, valueRange{}, fmt.Errorf("plotBar: unsupported input value type: %T", input)
+	}
+	slog.Debug("plotBar", "xAxisName", table.XAxisName)
+
+	seriesNames, err := table.selectColumns(call)
+	if err != nil {
+		return nil, valueRange{}, fmt.Errorf("plotBar: %w", err)
+	}
+	if err := table.applyMissingPolicy(call, seriesNames); err != nil {
+		return nil, valueRange{}, fmt.Errorf("plotBar: %w", err)
+	}
+	if err := table.applyTransform(call, seriesNames); err != nil {
+		return nil, valueRange{}, fmt.Errorf("plotBar: %w", err)
+	}
+	downsampleOpts, err := table.downsample(call, seriesNames, true)
+	if err != nil {
+		return nil, valueRange{}, err
 	}
 
 	// create a new bar instance
 	bar := charts.NewBar()
 
 	bar.SetGlobalOptions(buildGlobalChartOptions(call)...)
+	if downsampleOpts != nil {
+		bar.SetGlobalOptions(downsampleOpts)
+	}
 
 	// Reverse X/Y (only on bar charts)
 	if getBoolFlag(call, flags.XYReverse.Long) {
 		bar.XYReversal()
 	}
 
-	// Put data into instance
-	itemCount := 0
-	for sName, sValues := range series {
-		if sName == xAxisName {
-			continue
-		}
-
-		itemCount = len(sValues)
-		slog.Debug("plotBar: Adding items to series", "series", sName, "items", itemCount)
-		bar = bar.AddSeries(sName, sValues)
+	axisOpts, err := buildAxisOptions(call)
+	if err != nil {
+		return nil, valueRange{}, err
 	}
+	bar.SetGlobalOptions(axisOpts...)
 
-	if xAxisName != XAxisSeries {
-		bar = bar.SetXAxis(series[xAxisName])
+	// Dates on the x-axis are plotted on a time axis with proportional
+	// spacing and numbers on a logarithmic axis, if requested. This is not
+	// possible if the axes are reversed. All other x values are plotted as
+	// categories.
+	var positions []float64
+	if getBoolFlag(call, flags.XYReverse.Long) {
+		for _, flag := range []nu.Flag{flags.XLog, flags.YLog} {
+			if getBoolFlag(call, flag.Long) {
+				return nil, valueRange{}, flagError(call, flag, fmt.Errorf("logarithmic axes can not be combined with --%s", flags.XYReverse.Long))
+			}
+		}
 	} else {
-		xRange := make([]int, itemCount)
-		for i := range itemCount {
-			xRange[i] = i
+		var xAxisOpts []charts.GlobalOpts
+		if positions, xAxisOpts, err = table.xAxisOptions(call); err != nil {
+			return nil, valueRange{}, err
 		}
+		bar.SetGlobalOptions(xAxisOpts...)
+	}
+	var categories []any
+	if positions == nil {
+		categories = table.xValues()
+		bar = bar.SetXAxis(categories)
+	}
 
-		bar = bar.SetXAxis(xRange)
+	yValues := make([][]float64, 0, len(seriesNames))
+	for _, sName := range seriesNames {
+		yValues = append(yValues, table.Columns[sName])
+	}
+	if err := checkLogAxisValues(call, flags.YLog, yValues...); err != nil {
+		return nil, valueRange{}, err
 	}
 
+	// Put data into instance
+	plotted := newValueRange()
+	for _, sName := range seriesNames {
+		slog.Debug("plotBar: Adding items to series", "series", sName, "items", table.Rows)
+		plotted.add(table.Columns[sName]...)
+		bar = bar.AddSeries(sName, float64ToBarDataAt(positions, table.Columns[sName]), seriesKindOpts(table.Kinds[sName])...)
+	}
+	bar.SetGlobalOptions(withValueAxisKind(commonKind(table.Kinds, seriesNames), getBoolFlag(call, flags.XYReverse.Long)))
+
 	if getBoolFlag(call, flags.Stacked.Long) {
 		bar.SetSeriesOptions(
 			charts.WithBarChartOpts(opts.BarChart{
@@ -162,7 +204,29 @@ func plotBar(input any, call *nu.ExecCommand) error {
 		)
 	}
 
+	if getBoolFlag(call, flags.Compact.Long) {
+		// The categories of reversed bars are shown on the y-axis.
+		axis := "xAxis"
+		if getBoolFlag(call, flags.XYReverse.Long) {
+			axis = "yAxis"
+		}
+		if err := compactChart(&bar.BaseConfiguration, categories, axis); err != nil {
+			return nil, valueRange{}, fmt.Errorf("plotBar: %w", err)
+		}
+		// The categories are set by the decoder script.
+		bar = bar.SetXAxis(nil)
+	}
+
 	setPageTitle(call, &bar.BaseConfiguration)
 
+	return bar, plotted, nil
+}
+
+func plotBar(input any, call *nu.ExecCommand) error {
+	bar, _, err := buildBar(input, call)
+	if err != nil {
+		return err
+	}
+
 	return renderChart(func(f *os.File) error { return bar.Render(f) })
 }
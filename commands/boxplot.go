@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"time"
 
 	"github.com/go-echarts/go-echarts/v2/charts"
 	"github.com/go-echarts/go-echarts/v2/opts"
@@ -36,11 +37,24 @@ func NuplotBoxPlot() *nu.Command {
 			Name:        "nuplot boxplot",
 			Category:    "Chart",
 			Desc:        "Plots a boxplot chart",
-			Description: "Title, size and color theme can be configured by flags. Each column that contains numbers will be plottet. The X axis can be set by means of the --xaxis flag.",
+			Description: "Title, size and color theme can be configured by flags. Each column that contains numbers will be plottet. The X axis can be set by means of the --xaxis flag. The boxes are placed on a category axis, so only the y-axis can be logarithmic.",
 			SearchTerms: []string{"plot", "graph", "boxplot"},
 			// OptionalPositional: nu.PositionalArgs{},
 			Named: []nu.Flag{
 				flags.XAxis,
+				flags.Y,
+				flags.Exclude,
+				flags.Facet,
+				flags.FacetScales,
+				flags.FacetColumns,
+				flags.XName,
+				flags.YName,
+				flags.XMin,
+				flags.XMax,
+				flags.YMin,
+				flags.YMax,
+				flags.YLog,
+				flags.LogBase,
 				flags.Title,
 				flags.SubTitle,
 				flags.Width,
@@ -51,6 +65,8 @@ func NuplotBoxPlot() *nu.Command {
 			},
 			InputOutputTypes: []nu.InOutTypes{
 				{In: types.Table(types.RecordDef{}), Out: types.Nothing()},
+				{In: types.String(), Out: types.Nothing()},
+				{In: types.Binary(), Out: types.Nothing()},
 				{In: types.List(types.Number()), Out: types.Nothing()},
 				{In: types.List(types.Table(types.RecordDef{})), Out: types.Nothing()},
 				{In: types.List(types.List(types.Number())), Out: types.Nothing()},
@@ -77,7 +93,7 @@ func NuplotBoxPlot() *nu.Command {
 
 func nuplotBoxPlotHandler(ctx context.Context, call *nu.ExecCommand) error {
 	checkVerboseFlag(call)
-	return handleCommandInput(call, plotBoxPlot)
+	return handleCommandInput(call, withFacets(plotBoxPlot, buildBoxPlot))
 }
 
 func createBoxPlotDataValue(data []float64) ([]float64, error) {
@@ -106,6 +122,10 @@ func boxplotReadInputListItem(listItem []nu.Value, seriesHelper BoxPlotSeriesHel
 			// items := getSeries(seriesHelper, DefaultSeries)
 			items := getSeries(series, DefaultSeries)
 			series[DefaultSeries] = append(items, itemValue)
+		case nu.Filesize, time.Duration:
+			items := getSeries(series, DefaultSeries)
+			v, _ := ValueToFloat64(item)
+			series[DefaultSeries] = append(items, v)
 		case nu.Record:
 			// Try to set xAxisName to one of the columns in the record.
 			if itemIndex == 0 {
@@ -168,7 +188,7 @@ func boxplotReadInputListItem(listItem []nu.Value, seriesHelper BoxPlotSeriesHel
 	return
 }
 
-func plotBoxPlot(input any, call *nu.ExecCommand) error {
+func buildBoxPlot(input any, call *nu.ExecCommand) (*charts.BoxPlot, valueRange, error) {
 	seriesHelper := make(BoxPlotSeriesHelper)
 	var xSeries []any = nil
 
@@ -184,10 +204,10 @@ func plotBoxPlot(input any, call *nu.ExecCommand) error {
 				xSeries = items
 			}
 		} else {
-			return err
+			return nil, valueRange{}, err
 		}
 	default:
-		return fmt.Errorf("plotBoxPlot: unsupported input value type: %T", inputValue)
+		return nil, valueRange{}, fmt.Errorf("plotBoxPlot: unsupported input value type: %T", inputValue)
 	}
 
 	// create a new boxplot instance
@@ -195,12 +215,31 @@ func plotBoxPlot(input any, call *nu.ExecCommand) error {
 
 	boxplot.SetGlobalOptions(buildGlobalChartOptions(call)...)
 
+	seriesNames, err := selectSeries(seriesHelper, call, xAxisName)
+	if err != nil {
+		return nil, valueRange{}, fmt.Errorf("plotBoxPlot: %w", err)
+	}
+	if inputValue, ok := input.([]nu.Value); ok {
+		boxplot.SetGlobalOptions(withValueAxisKind(detectValueKind(inputValue, seriesNames), false))
+	}
+
+	axisOpts, err := buildAxisOptions(call)
+	if err != nil {
+		return nil, valueRange{}, err
+	}
+	boxplot.SetGlobalOptions(axisOpts...)
+
+	for _, sName := range seriesNames {
+		if err := checkLogAxisValues(call, flags.YLog, seriesHelper[sName]...); err != nil {
+			return nil, valueRange{}, err
+		}
+	}
+
 	// Put data into instance
 	itemCount := 0
-	for sName, sValues := range seriesHelper {
-		if sName == xAxisName {
-			continue
-		}
+	plotted := newValueRange()
+	for _, sName := range seriesNames {
+		sValues := seriesHelper[sName]
 
 		// Check, if series is completely empty. In this case, no box plot
 		// data is created for it.
@@ -221,12 +260,13 @@ func plotBoxPlot(input any, call *nu.ExecCommand) error {
 
 		data := make(BoxPlotDataList, 0)
 		for _, sVal := range sValues {
+			plotted.add(sVal...)
 			bpValues, err := createBoxPlotDataValue(sVal)
 			if err == nil {
 				data = append(data, opts.BoxPlotData{Value: bpValues})
 			} else {
 				// slog.Debug(err.Error())
-				return err
+				return nil, valueRange{}, err
 			}
 		}
 		boxplot = boxplot.AddSeries(sName, data)
@@ -246,5 +286,14 @@ func plotBoxPlot(input any, call *nu.ExecCommand) error {
 
 	setPageTitle(call, &boxplot.BaseConfiguration)
 
+	return boxplot, plotted, nil
+}
+
+func plotBoxPlot(input any, call *nu.ExecCommand) error {
+	boxplot, _, err := buildBoxPlot(input, call)
+	if err != nil {
+		return err
+	}
+
 	return renderChart(func(f *os.File) error { return boxplot.Render(f) })
 }
@@ -0,0 +1,213 @@
+package commands
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/ainvaltin/nu-plugin"
+
+	"github.com/gtnebel/nu_plugin_nuplot/commands/flags"
+)
+
+// Flags whose values are cell paths into the rows of the input table.
+var cellPathFlags = []nu.Flag{
+	flags.XAxis, flags.GroupBy, flags.Facet, flags.Value,
+	flags.Open, flags.Close, flags.Low, flags.High, flags.Volume,
+	flags.Error, flags.Lower, flags.Upper,
+	flags.Task, flags.Start, flags.End, flags.Group, flags.Status,
+	flags.Label,
+}
+
+// Flags that hold comma separated lists of cell paths.
+var cellPathListFlags = []nu.Flag{
+	flags.Y, flags.Y2,
+}
+
+// A single member of a cell path. Members are either column names or list
+// indices and can be marked as optional with a trailing "?".
+type cellPathMember struct {
+	Name     string
+	Index    int
+	IsIndex  bool
+	Optional bool
+}
+
+// A parsed cell path like "meta.timestamp", "values.0" or "meta?.host".
+type cellPath []cellPathMember
+
+// Parses the string representation of a cell path.
+func parseCellPath(path string) cellPath {
+	res := make(cellPath, 0)
+
+	for _, name := range strings.Split(path, ".") {
+		member := cellPathMember{Name: name}
+		if strings.HasSuffix(name, "?") {
+			member.Name = strings.TrimSuffix(name, "?")
+			member.Optional = true
+		}
+		if index, err := strconv.Atoi(member.Name); err == nil && index >= 0 {
+			member.Index = index
+			member.IsIndex = true
+		}
+		res = append(res, member)
+	}
+
+	return res
+}
+
+// Converts a cell path given as flag value. Its members are used directly, so
+// that column names with dots or digits are kept as they are. The type of each
+// member tells column names and list indices apart.
+func newCellPath(path nu.CellPath) cellPath {
+	res := make(cellPath, len(path.Members))
+
+	for i, member := range path.Members {
+		res[i] = cellPathMember{
+			Name:     member.Name,
+			Optional: member.Optional,
+		}
+		if member.Type == nu.PathVariantInt {
+			res[i].Index = int(member.Index)
+			res[i].IsIndex = true
+			res[i].Name = strconv.Itoa(res[i].Index)
+		}
+	}
+
+	return res
+}
+
+// Returns the string representation of the cell path, that is used as name
+// of the column with its values, e.g. "meta?.host".
+func (p cellPath) String() string {
+	names := make([]string, len(p))
+	for i, member := range p {
+		names[i] = member.Name
+		if member.Optional {
+			names[i] += "?"
+		}
+	}
+	return strings.Join(names, ".")
+}
+
+// Returns true, if the cell path is more than a plain column name.
+func (p cellPath) isNested() bool {
+	return len(p) > 1 || p[0].Optional || p[0].IsIndex
+}
+
+// Follows the cell path into the given value. Missing optional members result
+// in a null value, missing mandatory members in an error.
+func (p cellPath) follow(value nu.Value) (nu.Value, error) {
+	for _, member := range p {
+		var next nu.Value
+		found := false
+
+		switch v := value.Value.(type) {
+		case nu.Record:
+			next, found = v[member.Name]
+		case []nu.Value:
+			// Lists have no columns, so a name with digits is an index.
+			index, err := member.Index, error(nil)
+			if !member.IsIndex {
+				if index, err = strconv.Atoi(member.Name); err != nil || index < 0 {
+					return nu.Value{}, fmt.Errorf("can not access column %q of a list", member.Name)
+				}
+			}
+			if index < len(v) {
+				next, found = v[index], true
+			}
+		case nil:
+		default:
+			return nu.Value{}, fmt.Errorf("can not access %q of a %T value", member.Name, v)
+		}
+
+		if !found {
+			if member.Optional {
+				return nu.Value{Value: nil}, nil
+			}
+			return nu.Value{}, fmt.Errorf("%q not found", member.Name)
+		}
+		value = next
+	}
+
+	return value, nil
+}
+
+// Returns all nested cell paths given in the flags of the call by their
+// string representation. Plain column names are left out. The lists of
+// columns are given as strings and parsed, see [parseCellPath].
+func nestedCellPaths(call *nu.ExecCommand) map[string]cellPath {
+	paths := make(map[string]cellPath)
+
+	for _, flag := range cellPathFlags {
+		if value, _ := call.FlagValue(flag.Long); value.Value != nil {
+			if p := newCellPath(value.Value.(nu.CellPath)); len(p) > 0 && p.isNested() {
+				paths[p.String()] = p
+			}
+		}
+	}
+	for _, flag := range cellPathListFlags {
+		for _, name := range getStringListFlag(call, flag.Long) {
+			if p := parseCellPath(name); p.isNested() {
+				paths[name] = p
+			}
+		}
+	}
+
+	return paths
+}
+
+// Evaluates the given cell paths against a single record and stores the
+// results in the record under the names of the paths.
+func resolveRecordPaths(record nu.Record, paths map[string]cellPath) error {
+	for name, p := range paths {
+		// Column names that contain dots take precedence.
+		if _, ok := record[name]; ok {
+			continue
+		}
+
+		v, err := p.follow(nu.Value{Value: record})
+		if err != nil {
+			return fmt.Errorf("cell path %q: %w", name, err)
+		}
+		record[name] = v
+	}
+
+	return nil
+}
+
+// Evaluates all nested cell paths given in the flags of the call against the
+// records of the input. The results are stored in the records under the string
+// representation of the cell path, so that the plot functions can access them
+// like a plain column. Lists of records, like the input of the boxplot
+// command, are evaluated recursively.
+func resolveCellPaths(input any, call *nu.ExecCommand) error {
+	paths := nestedCellPaths(call)
+	if len(paths) == 0 {
+		return nil
+	}
+	slog.Debug("resolveCellPaths", "paths", len(paths))
+
+	var resolve func(list []nu.Value) error
+	resolve = func(list []nu.Value) error {
+		for itemIndex, item := range list {
+			switch itemValue := item.Value.(type) {
+			case nu.Record:
+				if err := resolveRecordPaths(itemValue, paths); err != nil {
+					return fmt.Errorf("resolveCellPaths: row %d: %w", itemIndex, err)
+				}
+			case []nu.Value:
+				if err := resolve(itemValue); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	if inputValue, ok := input.([]nu.Value); ok {
+		return resolve(inputValue)
+	}
+	return nil
+}
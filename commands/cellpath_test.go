@@ -0,0 +1,134 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ainvaltin/nu-plugin"
+)
+
+func TestParseCellPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want cellPath
+	}{
+		{"a", cellPath{{Name: "a"}}},
+		{"meta.timestamp", cellPath{{Name: "meta"}, {Name: "timestamp"}}},
+		{"values.0", cellPath{{Name: "values"}, {Name: "0", IsIndex: true}}},
+		{"meta?.host", cellPath{{Name: "meta", Optional: true}, {Name: "host"}}},
+		{"list.2?", cellPath{{Name: "list"}, {Name: "2", Index: 2, IsIndex: true, Optional: true}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got := parseCellPath(tt.path)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+			if got.String() != tt.path {
+				t.Errorf("got string %q, want %q", got.String(), tt.path)
+			}
+		})
+	}
+}
+
+func TestNewCellPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     nu.CellPath
+		want     string
+		isNested bool
+	}{
+		{
+			name: "column",
+			path: nu.CellPath{Members: []nu.PathMember{{Type: nu.PathVariantString, Name: "a"}}},
+			want: "a",
+		},
+		{
+			name: "column with dot",
+			path: nu.CellPath{Members: []nu.PathMember{{Type: nu.PathVariantString, Name: "a.b"}}},
+			want: "a.b",
+		},
+		{
+			name:     "nested",
+			path:     nu.CellPath{Members: []nu.PathMember{{Type: nu.PathVariantString, Name: "meta", Optional: true}, {Type: nu.PathVariantString, Name: "host"}}},
+			want:     "meta?.host",
+			isNested: true,
+		},
+		{
+			name:     "index",
+			path:     nu.CellPath{Members: []nu.PathMember{{Type: nu.PathVariantString, Name: "values"}, {Type: nu.PathVariantInt, Index: 3}}},
+			want:     "values.3",
+			isNested: true,
+		},
+		{
+			name:     "first index",
+			path:     nu.CellPath{Members: []nu.PathMember{{Type: nu.PathVariantString, Name: "values"}, {Type: nu.PathVariantInt, Index: 0}}},
+			want:     "values.0",
+			isNested: true,
+		},
+		{
+			name: "column named like an index",
+			path: nu.CellPath{Members: []nu.PathMember{{Type: nu.PathVariantString, Name: "3"}}},
+			want: "3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newCellPath(tt.path)
+			if got.String() != tt.want {
+				t.Errorf("got %q, want %q", got.String(), tt.want)
+			}
+			if got.isNested() != tt.isNested {
+				t.Errorf("got nested %v, want %v", got.isNested(), tt.isNested)
+			}
+		})
+	}
+}
+
+func TestCellPathFollow(t *testing.T) {
+	row := nu.Value{Value: nu.Record{
+		"meta": {Value: nu.Record{"host": {Value: "a"}}},
+		"values": {Value: []nu.Value{
+			{Value: int64(1)},
+			{Value: int64(2)},
+		}},
+		"empty": {Value: nil},
+	}}
+
+	tests := []struct {
+		path    string
+		want    any
+		wantErr bool
+	}{
+		{path: "meta.host", want: "a"},
+		{path: "values.1", want: int64(2)},
+		{path: "values.5?", want: nil},
+		{path: "values.5", wantErr: true},
+		{path: "values.x", wantErr: true},
+		{path: "meta.port", wantErr: true},
+		{path: "meta.port?", want: nil},
+		{path: "missing?.host", want: nil},
+		{path: "empty.host?", want: nil},
+		{path: "meta.host.name", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := parseCellPath(tt.path).follow(row)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("got %v, want an error", got.Value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Value != tt.want {
+				t.Errorf("got %v, want %v", got.Value, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+
+	"github.com/ainvaltin/nu-plugin"
+	"github.com/ainvaltin/nu-plugin/types"
+
+	"github.com/gtnebel/nu_plugin_nuplot/commands/flags"
+)
+
+// This function initializes the nuplot combo command.
+func NuplotCombo() *nu.Command {
+	return &nu.Command{
+		Signature: nu.PluginSignature{
+			Name:        "nuplot combo",
+			Category:    "Chart",
+			Desc:        "Plots a combined bar and line chart with an optional second y-axis.",
+			Description: "Title, size and color theme can be configured by flags. Each column that contains numbers will be plottet as bars, unless it is listed as line in --series-type. Columns listed in --y2 are plotted on a second y-axis on the right side. The X axis can be set by means of the --xaxis flag.",
+			SearchTerms: []string{"plot", "graph", "bar", "line", "combo"},
+			Named: []nu.Flag{
+				flags.XAxis,
+				flags.Y,
+				flags.Exclude,
+				flags.SeriesType,
+				flags.Y2,
+				flags.Stacked,
+				flags.XName,
+				flags.YName,
+				flags.XMin,
+				flags.XMax,
+				flags.YMin,
+				flags.YMax,
+				flags.XLog,
+				flags.YLog,
+				flags.LogBase,
+				flags.Title,
+				flags.SubTitle,
+				flags.Width,
+				flags.Height,
+				flags.ColorTheme,
+				flags.Fitted,
+				flags.Verbose,
+			},
+			InputOutputTypes: []nu.InOutTypes{
+				{In: types.Table(types.RecordDef{}), Out: types.Nothing()},
+				{In: types.String(), Out: types.Nothing()},
+				{In: types.Binary(), Out: types.Nothing()},
+			},
+			AllowMissingExamples: true,
+		},
+		Examples: []nu.Example{
+			{
+				Description: `Plot the request count as bars and the latency as line on a second y-axis.`,
+				Example:     `[[minute requests latency]; [1 120 35.2] [2 180 41.0] [3 90 28.7]] | nuplot combo --xaxis minute --series-type {latency: line} --y2 latency`,
+			},
+		},
+		OnRun: nuplotComboHandler,
+	}
+}
+
+func nuplotComboHandler(ctx context.Context, call *nu.ExecCommand) error {
+	checkVerboseFlag(call)
+	return handleTableInput(call, plotCombo)
+}
+
+// Reads the record of the --series-type flag into a map of column names to
+// series types, see [parseSeriesTypes].
+func getSeriesTypes(call *nu.ExecCommand) (map[string]string, error) {
+	value, _ := call.FlagValue(flags.SeriesType.Long)
+	if value.Value == nil {
+		return map[string]string{}, nil
+	}
+
+	record, ok := value.Value.(nu.Record)
+	if !ok {
+		return nil, flagError(call, flags.SeriesType, fmt.Errorf("expected a record like {column: line}, got %T", value.Value))
+	}
+
+	seriesTypes, err := parseSeriesTypes(record)
+	if err != nil {
+		return nil, flagError(call, flags.SeriesType, err)
+	}
+	return seriesTypes, nil
+}
+
+// Converts a record of column names and series types into a map. The type of
+// each column has to be bar or line.
+func parseSeriesTypes(record nu.Record) (map[string]string, error) {
+	seriesTypes := make(map[string]string, len(record))
+
+	for column, value := range record {
+		seriesType, _ := value.Value.(string)
+		if seriesType != "bar" && seriesType != "line" {
+			return nil, fmt.Errorf("invalid type %v of column %q, expected bar or line", value.Value, column)
+		}
+
+		seriesTypes[column] = seriesType
+	}
+
+	return seriesTypes, nil
+}
+
+// Checks that the columns given in the flag are numeric columns of the table.
+func checkComboColumns(call *nu.ExecCommand, flag nu.Flag, table *numericTable, columns []string) error {
+	for _, column := range columns {
+		if _, ok := table.Columns[column]; !ok {
+			return flagError(call, flag, fmt.Errorf("column %q not found, expected one of: %v", column, table.Names))
+		}
+	}
+	return nil
+}
+
+func plotCombo(input any, call *nu.ExecCommand) error {
+	table, ok := input.(*numericTable)
+	if !ok {
+		return fmt.Errorf("plotCombo: unsupported input value type: %T", input)
+	}
+	slog.Debug("plotCombo", "xAxisName", table.XAxisName)
+
+	seriesTypes, err := getSeriesTypes(call)
+	if err != nil {
+		return err
+	}
+	y2Columns := getStringListFlag(call, flags.Y2.Long)
+	slog.Debug("plotCombo", "seriesTypes", seriesTypes, "y2", y2Columns)
+
+	if err := checkComboColumns(call, flags.SeriesType, table, slices.Sorted(maps.Keys(seriesTypes))); err != nil {
+		return err
+	}
+	if err := checkComboColumns(call, flags.Y2, table, y2Columns); err != nil {
+		return err
+	}
+
+	bar, err := buildCombo(table, seriesTypes, y2Columns, call)
+	if err != nil {
+		return err
+	}
+
+	return renderChart(func(f *os.File) error { return bar.Render(f) })
+}
+
+// Builds a bar chart with the columns of the table. Columns with the type
+// line in seriesTypes are overlapped as line series and the columns in
+// y2Columns are plotted on a second y-axis on the right side.
+func buildCombo(table *numericTable, seriesTypes map[string]string, y2Columns []string, call *nu.ExecCommand) (*charts.Bar, error) {
+	seriesNames, err := table.selectColumns(call)
+	if err != nil {
+		return nil, fmt.Errorf("plotCombo: %w", err)
+	}
+
+	// create a new bar instance, the line series are overlapped onto it
+	bar := charts.NewBar()
+	line := charts.NewLine()
+
+	bar.SetGlobalOptions(buildGlobalChartOptions(call)...)
+
+	axisOpts, err := buildAxisOptions(call)
+	if err != nil {
+		return nil, err
+	}
+	bar.SetGlobalOptions(axisOpts...)
+
+	if len(y2Columns) > 0 {
+		bar.ExtendYAxis(opts.YAxis{
+			Name:     strings.Join(y2Columns, ", "),
+			Position: "right",
+			Scale:    opts.Bool(getBoolFlag(call, flags.Fitted.Long)),
+		})
+	}
+
+	// Dates on the x-axis are plotted on a time axis with proportional
+	// spacing and numbers on a logarithmic axis, if requested. All other x
+	// values are plotted as categories.
+	positions, xAxisOpts, err := table.xAxisOptions(call)
+	if err != nil {
+		return nil, err
+	}
+	bar.SetGlobalOptions(xAxisOpts...)
+
+	stack := ""
+	if getBoolFlag(call, flags.Stacked.Long) {
+		stack = "stackA"
+	}
+
+	// Only the series on the first y-axis are affected by --ylog.
+	for _, sName := range seriesNames {
+		if slices.Contains(y2Columns, sName) {
+			continue
+		}
+		if err := checkLogAxisValues(call, flags.YLog, table.Columns[sName]); err != nil {
+			return nil, err
+		}
+	}
+
+	// Put data into instance. Missing values are kept as empty values, so
+	// that all series stay aligned with the x-axis.
+	for _, sName := range seriesNames {
+		yAxisIndex := 0
+		if slices.Contains(y2Columns, sName) {
+			yAxisIndex = 1
+		}
+
+		slog.Debug("plotCombo: Adding items to series", "series", sName, "type", seriesTypes[sName], "yAxisIndex", yAxisIndex, "items", table.Rows)
+
+		if seriesTypes[sName] == "line" {
+			line.AddSeries(sName, float64ToLineDataAt(positions, table.Columns[sName]), charts.WithLineChartOpts(opts.LineChart{
+				YAxisIndex: yAxisIndex,
+				Smooth:     opts.Bool(true),
+			}))
+		} else {
+			bar.AddSeries(sName, float64ToBarDataAt(positions, table.Columns[sName]), charts.WithBarChartOpts(opts.BarChart{
+				YAxisIndex: yAxisIndex,
+				Stack:      stack,
+			}))
+		}
+	}
+
+	if positions == nil {
+		bar = bar.SetXAxis(table.xValues())
+	}
+
+	bar.Overlap(line)
+
+	setPageTitle(call, &bar.BaseConfiguration)
+
+	return bar, nil
+}
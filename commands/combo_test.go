@@ -0,0 +1,136 @@
+package commands
+
+import (
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/ainvaltin/nu-plugin"
+)
+
+func TestParseSeriesTypes(t *testing.T) {
+	tests := []struct {
+		name    string
+		record  nu.Record
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:   "empty record",
+			record: nu.Record{},
+			want:   map[string]string{},
+		},
+		{
+			name: "bar and line",
+			record: nu.Record{
+				"requests": {Value: "bar"},
+				"latency":  {Value: "line"},
+			},
+			want: map[string]string{"requests": "bar", "latency": "line"},
+		},
+		{
+			name:    "unknown type",
+			record:  nu.Record{"latency": {Value: "area"}},
+			wantErr: true,
+		},
+		{
+			name:    "type is no string",
+			record:  nu.Record{"latency": {Value: int64(1)}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSeriesTypes(tt.record)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildCombo(t *testing.T) {
+	input := records(
+		map[string]any{"minute": int64(1), "requests": int64(120), "errors": int64(3), "latency": 35.2},
+		map[string]any{"minute": int64(2), "requests": int64(180), "errors": int64(5), "latency": 41.0},
+		map[string]any{"minute": int64(3), "requests": int64(90), "errors": int64(1), "latency": 28.7},
+	)
+
+	type series struct {
+		Type       string
+		YAxisIndex int
+	}
+
+	tests := []struct {
+		name        string
+		seriesTypes map[string]string
+		y2Columns   []string
+		want        map[string]series
+		wantYAxes   int
+	}{
+		{
+			name: "all bars",
+			want: map[string]series{
+				"requests": {"bar", 0},
+				"errors":   {"bar", 0},
+				"latency":  {"bar", 0},
+			},
+			wantYAxes: 1,
+		},
+		{
+			name:        "line on the second y-axis",
+			seriesTypes: map[string]string{"latency": "line"},
+			y2Columns:   []string{"latency"},
+			want: map[string]series{
+				"requests": {"bar", 0},
+				"errors":   {"bar", 0},
+				"latency":  {"line", 1},
+			},
+			wantYAxes: 2,
+		},
+		{
+			name:        "bars on the second y-axis",
+			seriesTypes: map[string]string{"requests": "bar", "errors": "line"},
+			y2Columns:   []string{"requests", "latency"},
+			want: map[string]series{
+				"requests": {"bar", 1},
+				"errors":   {"line", 0},
+				"latency":  {"bar", 1},
+			},
+			wantYAxes: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, err := readNumericTable(input, "minute", time.UTC, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			bar, err := buildCombo(table, tt.seriesTypes, tt.y2Columns, &nu.ExecCommand{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make(map[string]series, len(bar.MultiSeries))
+			for _, s := range bar.MultiSeries {
+				got[s.Name] = series{s.Type, s.YAxisIndex}
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("got series %v, want %v", got, tt.want)
+			}
+
+			if len(bar.YAxisList) != tt.wantYAxes {
+				t.Fatalf("got %d y-axes, want %d", len(bar.YAxisList), tt.wantYAxes)
+			}
+			if tt.wantYAxes == 2 && bar.YAxisList[1].Position != "right" {
+				t.Errorf("got second y-axis at %q, want it on the right side", bar.YAxisList[1].Position)
+			}
+		})
+	}
+}
@@ -3,9 +3,11 @@
 package commands
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
 	"os"
 	"slices"
 	"strconv"
@@ -42,7 +44,7 @@ var Themes = []string{
 
 // Abstract data type so that [getSeries] can be called for all plot types.
 type ChartData interface {
-	float64 | []float64 | opts.LineData | opts.BarData | opts.PieData | opts.BoxPlotData | opts.KlineData
+	float64 | []float64 | opts.LineData | opts.BarData | opts.PieData | opts.BoxPlotData | opts.KlineData | opts.CustomData
 }
 
 // Retrieves a series with the given name from the series map. If the given
@@ -59,6 +61,44 @@ func getSeries[SeriesType ChartData](series map[string][]SeriesType, name string
 	}
 }
 
+// Returns the names of the series that should be plotted in a stable order.
+// The series are sorted by name, because [nu.Record] does not preserve the
+// column order of the input table, see [selectNamedSeries].
+func selectSeries[S any](series map[string]S, call *nu.ExecCommand, skip ...string) ([]string, error) {
+	return selectNamedSeries(slices.Sorted(maps.Keys(series)), call, skip...)
+}
+
+// Returns the names of the series that should be plotted. If the --y flag is
+// given, only the listed series are returned in the given order. Otherwise
+// all names are returned in their order. Series listed in --exclude and the
+// skip names (e.g. the x-axis) are left out.
+//
+// With --group-by, --y names the value columns and not the series, so it is
+// not used for the selection.
+func selectNamedSeries(names []string, call *nu.ExecCommand, skip ...string) ([]string, error) {
+	exclude := append(getStringListFlag(call, flags.Exclude.Long), skip...)
+
+	yColumns := getStringListFlag(call, flags.Y.Long)
+	if len(yColumns) == 0 || getCellPathFlag(call, flags.GroupBy.Long, "") != "" {
+		yColumns = names
+	} else {
+		for _, name := range yColumns {
+			if !slices.Contains(names, name) {
+				return nil, fmt.Errorf("column %q given in --y was not found or holds no numbers", name)
+			}
+		}
+	}
+
+	res := make([]string, 0, len(yColumns))
+	for _, name := range yColumns {
+		if !slices.Contains(exclude, name) {
+			res = append(res, name)
+		}
+	}
+
+	return res, nil
+}
+
 // Tries to find a column that is likely to be used as x-axis and returns it.
 func autoSetXaxis(rec nu.Record, xAxisName string) string {
 	// TODO: Find more column names for the x axis
@@ -97,15 +137,15 @@ func getStringFlag(call *nu.ExecCommand, name string, deflt string) string {
 // Retrieve the CellPath value of a flag from the call. The name of the flag and
 // a default value has to be provided.
 //
-// This function returns the string representation of the cell path. Chained
-// cell paths will be returned in "a.b" syntax.
-//
-// NOTE: Chained cell paths are not yet supported in the plotting commands.
+// This function returns the string representation of the cell path, see
+// [cellPath.String]. Chained cell paths will be returned in "a.b" syntax. They
+// are evaluated against the input by [resolveCellPaths] before the plot
+// function is called.
 func getCellPathFlag(call *nu.ExecCommand, name string, deflt string) string {
 	value, _ := call.FlagValue(name)
 
 	if value.Value != nil {
-		path := value.Value.(nu.CellPath)
+		path := newCellPath(value.Value.(nu.CellPath))
 		return path.String()
 	} else {
 		return deflt
@@ -124,6 +164,68 @@ func getIntFlag(call *nu.ExecCommand, name string, deflt int64) int64 {
 	}
 }
 
+// Retrieve a comma separated list of strings from a string flag, e.g.
+// "col1,col2". Whitespace around the items is removed. If the flag is not
+// given, nil is returned.
+func getStringListFlag(call *nu.ExecCommand, name string) []string {
+	value := getStringFlag(call, name, "")
+	if value == "" {
+		return nil
+	}
+
+	parts := strings.Split(value, ",")
+	res := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			res = append(res, part)
+		}
+	}
+
+	return res
+}
+
+// Retrieve a comma separated list of integers from a string flag, e.g.
+// "5,20,60". If the flag is not given, nil is returned.
+func getIntListFlag(call *nu.ExecCommand, name string) ([]int, error) {
+	value := getStringFlag(call, name, "")
+	if value == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(value, ",")
+	res := make([]int, 0, len(parts))
+	for _, part := range parts {
+		i, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q for flag --%s: %w", part, name, err)
+		}
+		res = append(res, i)
+	}
+
+	return res, nil
+}
+
+// Retrieve a comma separated list of floats from a string flag, e.g. "20,2".
+// If the flag is not given, nil is returned.
+func getFloatListFlag(call *nu.ExecCommand, name string) ([]float64, error) {
+	value := getStringFlag(call, name, "")
+	if value == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(value, ",")
+	res := make([]float64, 0, len(parts))
+	for _, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q for flag --%s: %w", part, name, err)
+		}
+		res = append(res, f)
+	}
+
+	return res, nil
+}
+
 // Retrieve the bool value of a flag from the call. The default value is false.
 func getBoolFlag(call *nu.ExecCommand, name string) bool {
 	value, _ := call.FlagValue(name)
@@ -135,13 +237,18 @@ func getBoolFlag(call *nu.ExecCommand, name string) bool {
 	}
 }
 
-// Convert an int or float nushell value to float64.
+// Convert an int, float, filesize or duration nushell value to float64.
+// Filesizes are converted to bytes and durations to nanoseconds.
 func ValueToFloat64(value nu.Value) (float64, error) {
 	switch v := value.Value.(type) {
 	case int64:
 		return float64(v), nil
 	case float64:
 		return v, nil
+	case nu.Filesize:
+		return float64(v), nil
+	case time.Duration:
+		return float64(v), nil
 	default:
 		return 0, fmt.Errorf("incompatible input type for ValueToFloat64(): %T", v)
 	}
@@ -151,12 +258,26 @@ func ValueToFloat64(value nu.Value) (float64, error) {
 // when data is loaded from CSV or JSON files, where dates and numbers are
 // sometimes represented as strings.
 func matchXValue(nuValue nu.Value) any {
+	return matchXValueInLocation(nuValue, nil)
+}
+
+// Like [matchXValue], but date strings without time zone are interpreted in
+// the given location. If loc is nil, ISO 8601 strings are interpreted as UTC
+// and other date strings as local time.
+func matchXValueInLocation(nuValue nu.Value, loc *time.Location) any {
 	const IsoDate = "2006-01-02 15:04:05 -07:00"
 	const IsoDate_Local = "2006-01-02 15:04:05"
 	const IsoDate_Date = "2006-01-02"
 
 	switch value := nuValue.Value.(type) {
 	case string:
+		localLoc := time.Local
+		if loc != nil {
+			localLoc = loc
+			if date, err := iso8601.ParseStringInLocation(value, loc); err == nil {
+				return date
+			}
+		}
 		if date, err := iso8601.ParseString(value); err == nil {
 			// slog.Debug("matchXValue: Value is ISO8601 date string")
 			return date
@@ -169,11 +290,11 @@ func matchXValue(nuValue nu.Value) any {
 			// slog.Debug("matchXValue: Value is ISO date string")
 			return date
 		}
-		if date, err := time.ParseInLocation(IsoDate_Local, value, time.Local); err == nil {
+		if date, err := time.ParseInLocation(IsoDate_Local, value, localLoc); err == nil {
 			// slog.Debug("matchXValue: Value is ISO date (local time) string")
 			return date
 		}
-		if date, err := time.ParseInLocation(IsoDate_Date, value, time.Local); err == nil {
+		if date, err := time.ParseInLocation(IsoDate_Date, value, localLoc); err == nil {
 			// slog.Debug("matchXValue: Value is ISO date (only date part) string")
 			return date
 		}
@@ -200,44 +321,60 @@ func checkVerboseFlag(call *nu.ExecCommand) {
 // This is the top level handler function that is called from the [nu.Command].
 // The function analyzes, in which format the input values are given and than
 // calls the provided plotFunc [PlotHandlerFunc] function.
+//
+// Streamed input is collected into a list before the plot function is called.
+// This is needed by the charts that read nested lists (boxplot, kline) or
+// label columns (pie, waterfall, gantt). The charts of numeric tables use
+// [handleTableInput], which reads the stream row by row.
 func handleCommandInput(call *nu.ExecCommand, plotFunc PlotHandlerFunc) error {
+	value, _, err := readCommandInput(call)
+	if err != nil || call.Input == nil {
+		return err
+	}
+
+	return plotFunc(value, call)
+}
+
+// Reads the input of the call into a nushell value, see [handleCommandInput].
+// The order of the columns is returned too, if it is known from raw input.
+// Nil is returned, if there is no input.
+func readCommandInput(call *nu.ExecCommand) (any, []string, error) {
 	switch in := call.Input.(type) {
 	case nil:
 		slog.Debug("handleCommandInput: Input is nil")
-		return nil
+		return nil, nil, nil
 	case nu.Value:
 		slog.Debug("handleCommandInput: Input is nu.Value")
-		return plotFunc(in.Value, call)
+		// Strings and binary values are decoded like byte streams.
+		switch v := in.Value.(type) {
+		case string:
+			return readRawInput(call, strings.NewReader(v))
+		case []byte:
+			return readRawInput(call, bytes.NewReader(v))
+		}
+		value := recordOfListsToTable(in.Value)
+		if err := resolveCellPaths(value, call); err != nil {
+			return nil, nil, err
+		}
+		return value, nil, nil
 	case <-chan nu.Value:
 		slog.Debug("handleCommandInput: Input is <-chan nu.Value")
+		// The complete input is buffered, see above.
 		inValues := make([]nu.Value, 0)
 
 		for v := range in {
 			inValues = append(inValues, v)
 		}
 
-		return plotFunc(inValues, call)
+		if err := resolveCellPaths(inValues, call); err != nil {
+			return nil, nil, err
+		}
+		return inValues, nil, nil
 	case io.Reader:
 		slog.Debug("handleCommandInput: Input is io.Reader")
-		// decoder wants io.ReadSeeker so we need to read to buf.
-		// could read just enough that the decoder can detect the
-		// format and stream the rest?
-		// buf, err := io.ReadAll(in)
-		// if err != nil {
-		// 	return fmt.Errorf("reding input: %w", err)
-		// }
-		// var v any
-		// if _, err := plist.Unmarshal(buf, &v); err != nil {
-		// 	return fmt.Errorf("decoding input as plist: %w", err)
-		// }
-		// rv, err := asValue(v)
-		// if err != nil {
-		// 	return fmt.Errorf("converting to Value: %w", err)
-		// }
-		// return call.ReturnValue(ctx, rv)
-		return fmt.Errorf("1 unsupported input type: %T", call.Input)
+		return readRawInput(call, in)
 	default:
-		return fmt.Errorf("2 unsupported input type: %T", call.Input)
+		return nil, nil, fmt.Errorf("2 unsupported input type: %T", call.Input)
 	}
 }
 
@@ -0,0 +1,321 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"slices"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+
+	"github.com/ainvaltin/nu-plugin"
+	"github.com/ainvaltin/nu-plugin/types"
+
+	"github.com/gtnebel/nu_plugin_nuplot/commands/flags"
+)
+
+// List of all available decomposition methods
+var DecomposeMethods = []string{"moving-average", "stl"}
+
+// Names of the components of a decomposed series in the order of the grids.
+var decomposeComponents = []string{"observed", "trend", "seasonal", "residual"}
+
+// This function initializes the nuplot decompose command.
+func NuplotDecompose() *nu.Command {
+	return &nu.Command{
+		Signature: nu.PluginSignature{
+			Name:        "nuplot decompose",
+			Category:    "Chart",
+			Desc:        "Plots the seasonal-trend decomposition of a time series.",
+			Description: "The series is split into trend, seasonal and residual components, that are plotted below the observed values in their own grids. The length of a season has to be given by means of the --period flag. The X axis can be set by means of the --xaxis flag.",
+			SearchTerms: []string{"plot", "graph", "decompose", "seasonal", "trend", "stl"},
+			Named: []nu.Flag{
+				flags.Period,
+				flags.Method,
+				flags.XAxis,
+				flags.Y,
+				flags.Exclude,
+				flags.Missing,
+				flags.XFormat,
+				flags.Timezone,
+				flags.Title,
+				flags.SubTitle,
+				flags.Width,
+				flags.Height,
+				flags.ColorTheme,
+				flags.Fitted,
+				flags.Verbose,
+			},
+			InputOutputTypes: []nu.InOutTypes{
+				{In: types.Table(types.RecordDef{}), Out: types.Nothing()},
+				{In: types.Record(types.RecordDef{}), Out: types.Nothing()},
+				{In: types.String(), Out: types.Nothing()},
+				{In: types.Binary(), Out: types.Nothing()},
+				{In: types.List(types.Number()), Out: types.Nothing()},
+			},
+			AllowMissingExamples: true,
+		},
+		Examples: []nu.Example{
+			{
+				Description: `Decompose daily traffic with weekly seasons.`,
+				Example:     `open traffic.csv | nuplot decompose --xaxis date --y visits --period 7`,
+			},
+			{
+				Description: `Decompose a series with the STL-style method.`,
+				Example:     `1..56 | each {|i| $i + ([0 3 5 3 0 -3 -5] | get ($i mod 7)) } | nuplot decompose --period 7 --method stl`,
+			},
+		},
+		OnRun: nuplotDecomposeHandler,
+	}
+}
+
+func nuplotDecomposeHandler(ctx context.Context, call *nu.ExecCommand) error {
+	checkVerboseFlag(call)
+	return handleTableInput(call, plotDecompose)
+}
+
+// Centered moving average over the given window. An even window is averaged
+// over window+1 values with half weights at both ends, as in the classical
+// decomposition. If partial is true, the window shrinks symmetrically at the
+// edges of the values, otherwise the averages at the edges are NaN.
+func centeredMovingAverage(values []float64, window int, partial bool) []float64 {
+	res := make([]float64, len(values))
+
+	for i := range values {
+		half, even := window/2, window%2 == 0
+		if edge := min(i, len(values)-1-i); edge < half {
+			if !partial {
+				res[i] = math.NaN()
+				continue
+			}
+			half, even = edge, false
+		}
+
+		sum, weight := 0.0, 0.0
+		for j := i - half; j <= i+half; j++ {
+			w := 1.0
+			if even && (j == i-half || j == i+half) {
+				w = 0.5
+			}
+			sum += w * values[j]
+			weight += w
+		}
+		res[i] = sum / weight
+	}
+
+	return res
+}
+
+// Classical additive decomposition. The trend is the centered moving average
+// over one period and the seasonal component the mean of the detrended values
+// at the same position in all periods.
+func classicalDecomposition(y []float64, period int) (trend, seasonal []float64) {
+	trend = centeredMovingAverage(y, period, false)
+
+	indices := make([]float64, period)
+	counts := make([]float64, period)
+	for i, v := range y {
+		if !math.IsNaN(trend[i]) {
+			indices[i%period] += v - trend[i]
+			counts[i%period]++
+		}
+	}
+
+	mean := 0.0
+	for k := range indices {
+		indices[k] /= counts[k]
+		mean += indices[k] / float64(period)
+	}
+
+	seasonal = make([]float64, len(y))
+	for i := range seasonal {
+		seasonal[i] = indices[i%period] - mean
+	}
+
+	return trend, seasonal
+}
+
+// Decomposition in the style of STL. Trend and seasonal component are
+// estimated alternately: the values of each position in the periods, the
+// cycle-subseries, are smoothed to get the seasonal component and the
+// deseasonalized values are smoothed to get the trend. Moving averages are
+// used instead of LOESS, so that the trend is defined up to the edges.
+func stlDecomposition(y []float64, period int) (trend, seasonal []float64) {
+	// The trend window is the smallest odd number of at least 1.5 periods.
+	trendWindow := (3*period+1)/2 | 1
+
+	trend = make([]float64, len(y))
+	seasonal = make([]float64, len(y))
+	detrended := make([]float64, len(y))
+	deseasonalized := make([]float64, len(y))
+
+	for range 3 {
+		for i, v := range y {
+			detrended[i] = v - trend[i]
+		}
+
+		for k := range period {
+			subseries := make([]float64, 0, len(y)/period+1)
+			for i := k; i < len(y); i += period {
+				subseries = append(subseries, detrended[i])
+			}
+			for j, v := range centeredMovingAverage(subseries, 3, true) {
+				seasonal[k+j*period] = v
+			}
+		}
+
+		// Remove the low frequencies, that belong to the trend. The edges,
+		// where no full period is available, keep the nearest average.
+		lowPass := centeredMovingAverage(seasonal, period, false)
+		for i := period / 2; i > 0; i-- {
+			lowPass[i-1] = lowPass[i]
+			lowPass[len(y)-i] = lowPass[len(y)-i-1]
+		}
+		for i := range seasonal {
+			seasonal[i] -= lowPass[i]
+			deseasonalized[i] = y[i] - seasonal[i]
+		}
+
+		trend = centeredMovingAverage(deseasonalized, trendWindow, true)
+	}
+
+	return trend, seasonal
+}
+
+func plotDecompose(input any, call *nu.ExecCommand) error {
+	period := int(getIntFlag(call, flags.Period.Long, 0))
+	if period < 2 {
+		return flagError(call, flags.Period, fmt.Errorf("the period needs at least 2 values, got %d", period))
+	}
+	method := getStringFlag(call, flags.Method.Long, flags.Method.Default.Value.(string))
+	if !slices.Contains(DecomposeMethods, method) {
+		return flagError(call, flags.Method, fmt.Errorf("invalid method %q, expected one of: %v", method, DecomposeMethods))
+	}
+	slog.Debug("plotDecompose", "period", period, "method", method)
+
+	table, ok := input.(*numericTable)
+	if !ok {
+		return fmt.Errorf("plotDecompose: unsupported input value type: %T", input)
+	}
+
+	seriesNames, err := table.selectColumns(call)
+	if err != nil {
+		return fmt.Errorf("plotDecompose: %w", err)
+	}
+	if len(seriesNames) != 1 {
+		return fmt.Errorf("plotDecompose: only a single series can be decomposed, found %q, use --y to select one", seriesNames)
+	}
+	name := seriesNames[0]
+
+	if err := table.applyMissingPolicy(call, seriesNames); err != nil {
+		return fmt.Errorf("plotDecompose: %w", err)
+	}
+	observed := table.Columns[name]
+	if slices.ContainsFunc(observed, math.IsNaN) {
+		return fmt.Errorf("plotDecompose: series %q has missing values, use --missing interpolate or drop-row", name)
+	}
+	if len(observed) < 2*period {
+		return fmt.Errorf("plotDecompose: %d values are not enough, at least two periods of %d values are needed", len(observed), period)
+	}
+
+	var trend, seasonal []float64
+	if method == "stl" {
+		trend, seasonal = stlDecomposition(observed, period)
+	} else {
+		trend, seasonal = classicalDecomposition(observed, period)
+	}
+	residual := make([]float64, len(observed))
+	for i, v := range observed {
+		residual[i] = v - trend[i] - seasonal[i]
+	}
+	components := [][]float64{observed, trend, seasonal, residual}
+
+	// create a new line instance
+	line := charts.NewLine()
+
+	line.SetGlobalOptions(buildGlobalChartOptions(call)...)
+
+	positions := table.timePositions()
+	xAxisType := "category"
+	var xData any
+	if positions != nil {
+		xAxisType = "time"
+		line.SetGlobalOptions(withTimeXAxis(call))
+	} else {
+		xData = table.xValues()
+		line = line.SetXAxis(xData)
+	}
+
+	// One grid per component, all grids share the zoom of the x-axis.
+	const top, bottom, gap = 10, 88, 6
+	height := (bottom - top - (len(components)-1)*gap) / len(components)
+	kind := table.Kinds[name]
+	grids := make([]opts.Grid, len(components))
+	xAxisIndex := make([]int, len(components))
+
+	for i, component := range decomposeComponents {
+		grids[i] = opts.Grid{
+			Left:   "8%",
+			Right:  "8%",
+			Top:    fmt.Sprintf("%d%%", top+i*(height+gap)),
+			Height: fmt.Sprintf("%d%%", height),
+		}
+		xAxisIndex[i] = i
+
+		yAxis := opts.YAxis{
+			Name:        component,
+			GridIndex:   i,
+			SplitNumber: 2,
+			Scale:       opts.Bool(true),
+		}
+		if formatter := kind.formatter(); formatter != "" {
+			yAxis.AxisLabel = &opts.AxisLabel{Formatter: opts.FuncOpts(formatter)}
+		}
+
+		if i == 0 {
+			line.SetGlobalOptions(withYAxisChange(func(first *opts.YAxis) {
+				first.Name = yAxis.Name
+				first.SplitNumber = yAxis.SplitNumber
+				first.Scale = yAxis.Scale
+				first.AxisLabel = yAxis.AxisLabel
+			}))
+		} else {
+			line.ExtendXAxis(opts.XAxis{
+				Type:      xAxisType,
+				GridIndex: i,
+				Data:      xData,
+				AxisLabel: &opts.AxisLabel{Show: opts.Bool(false)},
+			})
+			line.ExtendYAxis(yAxis)
+		}
+
+		seriesOpts := []charts.SeriesOpts{
+			charts.WithLineChartOpts(opts.LineChart{
+				XAxisIndex: i,
+				YAxisIndex: i,
+				ShowSymbol: opts.Bool(false),
+			}),
+		}
+		line.AddSeries(component, float64ToLineDataAt(positions, components[i]), append(seriesOpts, seriesKindOpts(kind)...)...)
+	}
+
+	line.SetGlobalOptions(
+		charts.WithGridOpts(grids...),
+		charts.WithAxisPointerOpts(&opts.AxisPointer{
+			Link: []opts.AxisPointerLink{{XAxisIndex: xAxisIndex}},
+		}),
+	)
+
+	// The data zoom components created in buildGlobalChartOptions only refer
+	// to the first x-axis. Link them to the other grids as well.
+	for i := range line.DataZoomList {
+		line.DataZoomList[i].XAxisIndex = xAxisIndex
+	}
+
+	setPageTitle(call, &line.BaseConfiguration)
+
+	return renderChart(func(f *os.File) error { return line.Render(f) })
+}
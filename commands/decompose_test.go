@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"math"
+	"testing"
+)
+
+// Returns a linear trend with additive seasons of the given pattern, whose
+// mean is 0.
+func seasonalSeries(periods int, pattern []float64) (y, trend []float64) {
+	for i := range periods * len(pattern) {
+		trend = append(trend, 10+0.5*float64(i))
+		y = append(y, trend[i]+pattern[i%len(pattern)])
+	}
+	return y, trend
+}
+
+func TestCenteredMovingAverage(t *testing.T) {
+	nan := math.NaN()
+
+	tests := []struct {
+		name    string
+		values  []float64
+		window  int
+		partial bool
+		want    []float64
+	}{
+		{"odd window", []float64{1, 2, 3, 4, 5}, 3, false, []float64{nan, 2, 3, 4, nan}},
+		{"partial edges", []float64{1, 2, 3, 4, 5}, 3, true, []float64{1, 2, 3, 4, 5}},
+		// Half weights at both ends: (0.5·1 + 2 + 0.5·9) / 2
+		{"even window", []float64{1, 2, 9}, 2, false, []float64{nan, 3.5, nan}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := centeredMovingAverage(tt.values, tt.window, tt.partial); !equalFloats(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecomposition(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern []float64
+		decomp  func(y []float64, period int) (trend, seasonal []float64)
+		// The moving averages of the STL-style method only approximate the
+		// components, so they are checked two periods away from the edges.
+		tolerance float64
+		edge      int
+	}{
+		{"classical odd period", []float64{2, -1, -1}, classicalDecomposition, 1e-9, 0},
+		{"classical even period", []float64{3, 1, -1, -3}, classicalDecomposition, 1e-9, 0},
+		{"stl", []float64{0, 3, 5, 3, 0, -3, -5, -3}, stlDecomposition, 0.1, 16},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			period := len(tt.pattern)
+			y, wantTrend := seasonalSeries(6, tt.pattern)
+
+			trend, seasonal := tt.decomp(y, period)
+			if len(trend) != len(y) || len(seasonal) != len(y) {
+				t.Fatalf("got %d trend and %d seasonal values, want %d", len(trend), len(seasonal), len(y))
+			}
+
+			for i := tt.edge; i < len(y)-tt.edge; i++ {
+				// The seasonal component repeats the pattern.
+				if math.Abs(seasonal[i]-tt.pattern[i%period]) > tt.tolerance {
+					t.Fatalf("got seasonal component %v at %d, want %v", seasonal[i], i, tt.pattern[i%period])
+				}
+				// The classical trend is undefined at the edges.
+				if !math.IsNaN(trend[i]) && math.Abs(trend[i]-wantTrend[i]) > tt.tolerance {
+					t.Fatalf("got trend %v at %d, want %v", trend[i], i, wantTrend[i])
+				}
+			}
+		})
+	}
+}
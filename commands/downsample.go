@@ -0,0 +1,218 @@
+package commands
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+
+	"github.com/ainvaltin/nu-plugin"
+
+	"github.com/gtnebel/nu_plugin_nuplot/commands/flags"
+)
+
+// Selects up to threshold points of the series with the
+// Largest-Triangle-Three-Buckets algorithm and returns their indices. The
+// first and the last point are always selected.
+func lttb(x []float64, y []float64, threshold int) []int {
+	if threshold >= len(y) || threshold < 3 {
+		res := make([]int, len(y))
+		for i := range y {
+			res[i] = i
+		}
+		return res
+	}
+
+	res := make([]int, 0, threshold)
+	res = append(res, 0)
+
+	// The points between the first and the last one are split into
+	// threshold-2 buckets. From each bucket the point is selected that forms
+	// the largest triangle with the previously selected point and the average
+	// of the next bucket.
+	bucketSize := float64(len(y)-2) / float64(threshold-2)
+	selected := 0
+
+	for bucket := range threshold - 2 {
+		start := int(float64(bucket)*bucketSize) + 1
+		end := int(float64(bucket+1)*bucketSize) + 1
+
+		nextStart := end
+		nextEnd := min(int(float64(bucket+2)*bucketSize)+1, len(y))
+		avgX, avgY := 0.0, 0.0
+		for i := nextStart; i < nextEnd; i++ {
+			avgX += x[i]
+			avgY += y[i]
+		}
+		if n := float64(nextEnd - nextStart); n > 0 {
+			avgX /= n
+			avgY /= n
+		}
+
+		maxArea := -1.0
+		maxIndex := start
+		for i := start; i < end; i++ {
+			area := math.Abs((x[selected]-avgX)*(y[i]-y[selected]) - (x[selected]-x[i])*(avgY-y[selected]))
+			if area > maxArea {
+				maxArea = area
+				maxIndex = i
+			}
+		}
+
+		res = append(res, maxIndex)
+		selected = maxIndex
+	}
+
+	return append(res, len(y)-1)
+}
+
+// Selects the points with the smallest and the largest value of each of
+// buckets buckets and returns their indices in ascending order.
+func minMaxBuckets(y []float64, buckets int) []int {
+	if buckets*2 >= len(y) || buckets < 1 {
+		res := make([]int, len(y))
+		for i := range y {
+			res[i] = i
+		}
+		return res
+	}
+
+	res := make([]int, 0, buckets*2)
+	bucketSize := float64(len(y)) / float64(buckets)
+
+	for bucket := range buckets {
+		start := int(float64(bucket) * bucketSize)
+		end := int(float64(bucket+1) * bucketSize)
+
+		minIndex, maxIndex := start, start
+		for i := start; i < end; i++ {
+			if y[i] < y[minIndex] {
+				minIndex = i
+			}
+			if y[i] > y[maxIndex] {
+				maxIndex = i
+			}
+		}
+
+		res = append(res, min(minIndex, maxIndex))
+		if minIndex != maxIndex {
+			res = append(res, max(minIndex, maxIndex))
+		}
+	}
+
+	return res
+}
+
+// Reduces the rows of the table to at most --max-points rows, if the flag is
+// given, see [numericTable.downsampleRows].
+//
+// The returned option adds the original number of points and the ratio to the
+// subtitle of the chart. It is nil, if the table is not downsampled.
+func (t *numericTable) downsample(call *nu.ExecCommand, names []string, minMax bool) (charts.GlobalOpts, error) {
+	maxPoints := int(getIntFlag(call, flags.MaxPoints.Long, 0))
+	if maxPoints == 0 || t.Rows <= maxPoints {
+		return nil, nil
+	}
+	if maxPoints < 3 {
+		return nil, flagError(call, flags.MaxPoints, fmt.Errorf("at least 3 points are needed, got %d", maxPoints))
+	}
+
+	keep := t.downsampleRows(names, maxPoints, minMax)
+
+	rows := t.Rows
+	t.dropRows(func(row int) bool { return !keep[row] })
+	slog.Debug("downsample", "rows", rows, "kept", t.Rows, "minMax", minMax)
+
+	subtitle := fmt.Sprintf("%d points downsampled to %d (1:%.1f)", rows, t.Rows, float64(rows)/float64(max(t.Rows, 1)))
+	return func(bc *charts.BaseConfiguration) {
+		if bc.Title.Subtitle != "" {
+			bc.Title.Subtitle += " - "
+		}
+		bc.Title.Subtitle += subtitle
+	}, nil
+}
+
+// The points of a column that take part in the downsampling.
+type downsampleSeries struct {
+	// The rows of the points in the table
+	Rows []int
+	X    []float64
+	Y    []float64
+}
+
+// Selects the rows that are kept, if the table is downsampled to maxPoints
+// rows. Each of the given columns is downsampled on its own, either with
+// [lttb] or with [minMaxBuckets], and all rows are kept that are selected for
+// at least one column. So all columns stay aligned with the x-axis.
+//
+// The columns select different rows, so the points of each column are
+// reduced until all selected rows fit into maxPoints. Only if there are more
+// columns than can be shown with 3 points each, more rows are kept.
+func (t *numericTable) downsampleRows(names []string, maxPoints int, minMax bool) []bool {
+	x := t.timePositions()
+	if x == nil {
+		x = t.numericPositions()
+	}
+
+	series := make([]downsampleSeries, len(names))
+	for i, name := range names {
+		// Missing values are not part of the downsampling, they are kept
+		// only if the row is selected for another column.
+		s := downsampleSeries{Rows: make([]int, 0, t.Rows)}
+		for row, v := range t.Columns[name] {
+			if !math.IsNaN(v) {
+				s.Rows = append(s.Rows, row)
+			}
+		}
+
+		s.X = make([]float64, len(s.Rows))
+		s.Y = make([]float64, len(s.Rows))
+		for j, row := range s.Rows {
+			s.X[j] = float64(row)
+			if x != nil {
+				s.X[j] = x[row]
+			}
+			s.Y[j] = t.Columns[name][row]
+		}
+		series[i] = s
+	}
+
+	selectRows := func(points int) ([]bool, int) {
+		keep := make([]bool, t.Rows)
+		kept := 0
+		for _, s := range series {
+			var selected []int
+			if minMax {
+				selected = minMaxBuckets(s.Y, points/2)
+			} else {
+				selected = lttb(s.X, s.Y, points)
+			}
+			for _, i := range selected {
+				if !keep[s.Rows[i]] {
+					keep[s.Rows[i]] = true
+					kept++
+				}
+			}
+		}
+		return keep, kept
+	}
+
+	keep, kept := selectRows(maxPoints)
+	if kept <= maxPoints {
+		return keep
+	}
+
+	// Search the largest number of points per column that fits.
+	low, high := 3, maxPoints-1
+	for low < high {
+		mid := (low + high + 1) / 2
+		if _, kept := selectRows(mid); kept <= maxPoints {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+	keep, _ = selectRows(low)
+	return keep
+}
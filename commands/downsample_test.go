@@ -0,0 +1,159 @@
+package commands
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+// Returns a sine wave with a single spike.
+func spikedWave(n, spike int) ([]float64, []float64) {
+	x := make([]float64, n)
+	y := make([]float64, n)
+	for i := range n {
+		x[i] = float64(i)
+		y[i] = math.Sin(float64(i) / 10)
+	}
+	y[spike] = 100
+	return x, y
+}
+
+func TestLTTB(t *testing.T) {
+	tests := []struct {
+		name      string
+		n         int
+		threshold int
+		want      int
+	}{
+		{"threshold above length", 10, 20, 10},
+		{"threshold too small", 10, 2, 10},
+		{"reduced", 1000, 100, 100},
+		{"odd bucket size", 1001, 7, 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x, y := spikedWave(tt.n, tt.n/2)
+
+			got := lttb(x, y, tt.threshold)
+			if len(got) != tt.want {
+				t.Fatalf("got %d points, want %d", len(got), tt.want)
+			}
+			if got[0] != 0 || got[len(got)-1] != tt.n-1 {
+				t.Errorf("got first %d and last %d, want 0 and %d", got[0], got[len(got)-1], tt.n-1)
+			}
+			if !slices.IsSorted(got) || len(slices.Compact(slices.Clone(got))) != len(got) {
+				t.Errorf("got indices %v, want strictly ascending indices", got)
+			}
+			if !slices.Contains(got, tt.n/2) {
+				t.Errorf("the spike at %d was not selected", tt.n/2)
+			}
+		})
+	}
+}
+
+func TestMinMaxBuckets(t *testing.T) {
+	tests := []struct {
+		name    string
+		n       int
+		buckets int
+		max     int
+	}{
+		{"buckets above length", 10, 5, 10},
+		{"no buckets", 10, 0, 10},
+		{"reduced", 1000, 50, 100},
+		{"odd bucket size", 999, 7, 14},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, y := spikedWave(tt.n, tt.n/3)
+
+			got := minMaxBuckets(y, tt.buckets)
+			if len(got) > tt.max {
+				t.Fatalf("got %d points, want at most %d", len(got), tt.max)
+			}
+			if !slices.IsSorted(got) || len(slices.Compact(slices.Clone(got))) != len(got) {
+				t.Errorf("got indices %v, want strictly ascending indices", got)
+			}
+
+			// The extremes of the series are always kept.
+			lo, hi := 0, 0
+			for i, v := range y {
+				if v < y[lo] {
+					lo = i
+				}
+				if v > y[hi] {
+					hi = i
+				}
+			}
+			if !slices.Contains(got, lo) || !slices.Contains(got, hi) {
+				t.Errorf("got %v, want the minimum at %d and the maximum at %d", got, lo, hi)
+			}
+		})
+	}
+}
+
+func TestMinMaxBucketsConstant(t *testing.T) {
+	// Buckets with a single distinct value contribute a single point.
+	got := minMaxBuckets(make([]float64, 100), 10)
+	if want := []int{0, 10, 20, 30, 40, 50, 60, 70, 80, 90}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestDownsampleRows(t *testing.T) {
+	const rows = 1000
+	names := []string{"a", "b", "c", "d"}
+	spikes := []int{100, 300, 500, 700}
+
+	// The series have their spikes at different rows, so that they select
+	// different rows.
+	input := make([]map[string]any, rows)
+	for i := range input {
+		input[i] = map[string]any{"x": int64(i)}
+	}
+	for s, name := range names {
+		_, y := spikedWave(rows, spikes[s])
+		for i, v := range y {
+			input[i][name] = v + float64(s)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		minMax    bool
+		maxPoints int
+	}{
+		{"lttb", false, 50},
+		{"lttb with many points", false, 400},
+		{"min/max", true, 50},
+		{"min/max with many points", true, 400},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, err := readNumericTable(records(input...), "x", time.UTC, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			keep := table.downsampleRows(names, tt.maxPoints, tt.minMax)
+			kept := 0
+			for _, k := range keep {
+				if k {
+					kept++
+				}
+			}
+			if kept > tt.maxPoints || kept < tt.maxPoints/2 {
+				t.Errorf("got %d rows, want at most %d and at least half of them", kept, tt.maxPoints)
+			}
+			for _, spike := range spikes {
+				if !keep[spike] {
+					t.Errorf("got spike at row %d dropped, want it to be kept", spike)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,422 @@
+package commands
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+)
+
+// Javascript that decodes the series data embedded by [compactChart] and sets
+// it on the chart. The payload is inserted with fmt.Sprintf. The script must
+// not contain line comments, because all newlines are removed.
+const compactDecoder = `(function () {
+	var payload = %s;
+	var decode = function (data) {
+		var bytes = atob(data);
+		var buffer = new Uint8Array(bytes.length);
+		for (var i = 0; i < bytes.length; i++) {
+			buffer[i] = bytes.charCodeAt(i);
+		}
+		return buffer.buffer;
+	};
+	var types = {u8: Uint8Array, u16: Uint16Array, u32: Uint32Array, d32: Int32Array, f32: Float32Array, f64: Float64Array};
+	var missing = {u8: 0xff, u16: 0xffff, u32: 0xffffffff};
+	var decodeArray = function (a) {
+		var res = new Float64Array(a.n);
+		if (a.t === 'range') {
+			for (var i = 0; i < a.n; i++) {
+				res[i] = a.offset + i * a.step;
+			}
+			return res;
+		}
+		var raw = new types[a.t](decode(a.d));
+		var sum = a.offset;
+		for (var i = 0; i < a.n; i++) {
+			if (a.t === 'f32' || a.t === 'f64') {
+				res[i] = raw[i];
+			} else if (a.t === 'd32') {
+				sum += raw[i];
+				res[i] = sum / a.scale;
+			} else {
+				res[i] = raw[i] === missing[a.t] ? NaN : (a.offset + raw[i]) / a.scale;
+			}
+		}
+		return res;
+	};
+	var positions = payload.positions.map(decodeArray);
+	var option = {series: []};
+	payload.series.forEach(function (s) {
+		if (!s.values) {
+			option.series.push({});
+			return;
+		}
+		var values = decodeArray(s.values);
+		var x = s.positions >= 0 ? positions[s.positions] : null;
+		var data = new Array(values.length);
+		for (var i = 0; i < values.length; i++) {
+			var v = isNaN(values[i]) ? '-' : values[i];
+			data[i] = x ? [x[i], v] : v;
+		}
+		option.series.push({data: data});
+	});
+	if (payload.categories) {
+		var indices = new Uint32Array(decode(payload.categories.indices));
+		var categories = new Array(indices.length);
+		for (var i = 0; i < indices.length; i++) {
+			categories[i] = payload.categories.values[indices[i]];
+		}
+		option[payload.categories.axis] = [{data: categories}];
+	}
+	%%MY_ECHARTS%%.setOption(option);
+})();`
+
+// The series data of a chart in binary form, as read by [compactDecoder].
+type compactPayload struct {
+	Series []compactSeries `json:"series"`
+	// The x positions of the series. Series with the same positions share an
+	// entry.
+	Positions  []*compactArray    `json:"positions"`
+	Categories *compactCategories `json:"categories,omitempty"`
+}
+
+// The data of a single series. Series that can not be encoded keep their
+// data in the chart options and have no values.
+type compactSeries struct {
+	Values *compactArray `json:"values,omitempty"`
+	// Index into the positions of the payload or -1 for category axes.
+	Positions int `json:"positions"`
+}
+
+// An array of floats in the smallest binary form, that keeps all values
+// exactly, see [encodeArray]. Missing values are NaN.
+type compactArray struct {
+	// The encoding: "range", "u8", "u16", "u32", "d32", "f32" or "f64".
+	Type string `json:"t"`
+	// The number of values.
+	Len int `json:"n"`
+	// Base64 encoded typed array of the given type, empty for ranges.
+	Data string `json:"d,omitempty"`
+	// The integer encodings store (value·Scale - Offset), ranges start at
+	// Offset and grow by Step.
+	Offset float64 `json:"offset"`
+	Scale  float64 `json:"scale,omitempty"`
+	Step   float64 `json:"step"`
+}
+
+// Dictionary encoded categories of an axis.
+type compactCategories struct {
+	// The name of the axis in the chart options, "xAxis" or "yAxis".
+	Axis string `json:"axis"`
+	// The distinct categories.
+	Values []any `json:"values"`
+	// Base64 encoded Uint32Array with an index into Values per data point.
+	Indices string `json:"indices"`
+}
+
+// The largest number of decimal places, that are tried to turn values into
+// integers, see [encodeArray].
+const maxDecimals = 6
+
+// Encodes floats into the smallest array that decodes to the same values:
+//
+//   - "range" for evenly spaced values, e.g. the positions of a regular time
+//     series, that need no data at all,
+//   - "u8", "u16" or "u32" for values with at most [maxDecimals] decimal
+//     places, that are stored as integers relative to their minimum, the
+//     largest integer of the type marks missing values,
+//   - "d32" for such values, whose range is too large for 32 bits, but not
+//     the differences of neighbouring values, e.g. timestamps in ms,
+//   - "f32" for values that are exact as float32 and "f64" otherwise.
+func encodeArray(values []float64) *compactArray {
+	res := &compactArray{Len: len(values)}
+
+	if step, ok := arithmeticStep(values); ok {
+		res.Type, res.Offset, res.Step = "range", values[0], step
+		return res
+	}
+
+	if ints, scale, ok := scaledIntegers(values); ok {
+		res.Scale = scale
+		if encodeIntegers(res, ints) {
+			return res
+		}
+	}
+
+	res.Scale = 0
+	if slices.IndexFunc(values, func(v float64) bool { return float64(float32(v)) != v && !math.IsNaN(v) }) < 0 {
+		buf := make([]byte, 4*len(values))
+		for i, v := range values {
+			binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(float32(v)))
+		}
+		res.Type, res.Data = "f32", base64.StdEncoding.EncodeToString(buf)
+		return res
+	}
+
+	buf := make([]byte, 8*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint64(buf[8*i:], math.Float64bits(v))
+	}
+	res.Type, res.Data = "f64", base64.StdEncoding.EncodeToString(buf)
+	return res
+}
+
+// Returns the step of values that grow by the same amount from one to the
+// next. The decoder computes Offset + i·Step, so the values have to be
+// reproduced exactly by this formula.
+func arithmeticStep(values []float64) (float64, bool) {
+	if len(values) < 2 {
+		return 0, false
+	}
+
+	step := values[1] - values[0]
+	for i, v := range values {
+		// The explicit conversion prevents a fused multiply-add, which
+		// rounds differently than the decoder.
+		if values[0]+float64(float64(i)*step) != v {
+			return 0, false
+		}
+	}
+	return step, true
+}
+
+// Turns the values into integers by multiplying them with the smallest power
+// of ten, that gives integers that are divided back into exactly the same
+// values. Missing values stay NaN.
+func scaledIntegers(values []float64) ([]float64, float64, bool) {
+	ints := make([]float64, len(values))
+
+	for decimals := range maxDecimals + 1 {
+		scale := math.Pow10(decimals)
+		exact := true
+		for i, v := range values {
+			if math.IsNaN(v) {
+				ints[i] = v
+				continue
+			}
+			r := math.Round(v * scale)
+			if r/scale != v || math.Abs(r) > 1<<53 {
+				exact = false
+				break
+			}
+			ints[i] = r
+		}
+		if exact {
+			return ints, scale, true
+		}
+	}
+
+	return nil, 0, false
+}
+
+// Stores integers, as returned by [scaledIntegers], in the smallest unsigned
+// array relative to their minimum or as differences of neighbouring values.
+// It returns false, if the integers fit into neither.
+func encodeIntegers(res *compactArray, ints []float64) bool {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, v := range ints {
+		if !math.IsNaN(v) {
+			lo, hi = math.Min(lo, v), math.Max(hi, v)
+		}
+	}
+	if math.IsInf(lo, 1) {
+		lo, hi = 0, 0
+	}
+
+	var width int
+	var missing uint32
+	var put func(buf []byte, i int, v uint32)
+	switch span := hi - lo; {
+	case span < math.MaxUint8:
+		res.Type, width, missing = "u8", 1, math.MaxUint8
+		put = func(buf []byte, i int, v uint32) { buf[i] = byte(v) }
+	case span < math.MaxUint16:
+		res.Type, width, missing = "u16", 2, math.MaxUint16
+		put = func(buf []byte, i int, v uint32) { binary.LittleEndian.PutUint16(buf[2*i:], uint16(v)) }
+	case span < math.MaxUint32:
+		res.Type, width, missing = "u32", 4, math.MaxUint32
+		put = func(buf []byte, i int, v uint32) { binary.LittleEndian.PutUint32(buf[4*i:], v) }
+	default:
+		return encodeDeltas(res, ints)
+	}
+
+	buf := make([]byte, width*len(ints))
+	for i, v := range ints {
+		if math.IsNaN(v) {
+			put(buf, i, missing)
+		} else {
+			put(buf, i, uint32(v-lo))
+		}
+	}
+	res.Offset, res.Data = lo, base64.StdEncoding.EncodeToString(buf)
+	return true
+}
+
+// Stores integers as int32 differences of neighbouring values. This is not
+// possible with missing values or larger differences.
+func encodeDeltas(res *compactArray, ints []float64) bool {
+	buf := make([]byte, 4*len(ints))
+	prev := ints[0]
+	for i, v := range ints {
+		d := v - prev
+		if math.IsNaN(d) || d < math.MinInt32 || d > math.MaxInt32 {
+			return false
+		}
+		binary.LittleEndian.PutUint32(buf[4*i:], uint32(int32(d)))
+		prev = v
+	}
+
+	res.Type, res.Offset, res.Data = "d32", ints[0], base64.StdEncoding.EncodeToString(buf)
+	return true
+}
+
+// Encodes the categories of an axis with a dictionary of the distinct values.
+func encodeCategories(axis string, categories []any) *compactCategories {
+	res := &compactCategories{Axis: axis, Values: make([]any, 0)}
+	dict := make(map[any]uint32)
+
+	buf := make([]byte, 4*len(categories))
+	for i, c := range categories {
+		index, ok := dict[c]
+		if !ok {
+			index = uint32(len(res.Values))
+			dict[c] = index
+			res.Values = append(res.Values, c)
+		}
+		binary.LittleEndian.PutUint32(buf[4*i:], index)
+	}
+	res.Indices = base64.StdEncoding.EncodeToString(buf)
+
+	return res
+}
+
+// Converts the value of a data point to float64. The echarts placeholder for
+// empty values is converted to NaN.
+func numberOrMissing(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		return math.NaN(), v == "-"
+	default:
+		return 0, false
+	}
+}
+
+// Returns the values and the x positions of series data that consists of
+// plain numbers or [x, y] pairs of numbers, as built by [float64ToLineDataAt]
+// and [float64ToBarDataAt]. The returned bool is false, if the data holds
+// anything else, e.g. data points with their own style.
+func seriesDataValues(data any) ([]float64, []float64, bool) {
+	var items []any
+	switch d := data.(type) {
+	case []opts.LineData:
+		items = make([]any, len(d))
+		for i, item := range d {
+			if item.Name != "" || item.Symbol != "" || item.SymbolSize != 0 {
+				return nil, nil, false
+			}
+			items[i] = item.Value
+		}
+	case []opts.BarData:
+		items = make([]any, len(d))
+		for i, item := range d {
+			if item.Name != "" || item.Label != nil || item.ItemStyle != nil || item.Tooltip != nil {
+				return nil, nil, false
+			}
+			items[i] = item.Value
+		}
+	default:
+		return nil, nil, false
+	}
+	if len(items) == 0 {
+		return nil, nil, false
+	}
+
+	values := make([]float64, len(items))
+	var positions []float64
+	if _, isPair := items[0].([]any); isPair {
+		positions = make([]float64, len(items))
+	}
+
+	for i, item := range items {
+		pair, isPair := item.([]any)
+		if isPair != (positions != nil) {
+			return nil, nil, false
+		}
+		if isPair {
+			if len(pair) != 2 {
+				return nil, nil, false
+			}
+			x, ok := pair[0].(float64)
+			if !ok {
+				return nil, nil, false
+			}
+			positions[i] = x
+			item = pair[1]
+		}
+
+		v, ok := numberOrMissing(item)
+		if !ok {
+			return nil, nil, false
+		}
+		values[i] = v
+	}
+
+	return values, positions, true
+}
+
+// Moves the series data and the categories of the chart out of the chart
+// options into base64 encoded binary arrays, that are decoded by a small
+// script when the page is loaded. The categories belong to the given axis,
+// "xAxis" or "yAxis", and have to be removed from the chart by the caller.
+// Series that can not be encoded are left untouched.
+func compactChart(bc *charts.BaseConfiguration, categories []any, axis string) error {
+	payload := compactPayload{
+		Series:    make([]compactSeries, len(bc.MultiSeries)),
+		Positions: make([]*compactArray, 0),
+	}
+	positionIndex := make(map[string]int)
+
+	for i := range bc.MultiSeries {
+		payload.Series[i].Positions = -1
+
+		values, positions, ok := seriesDataValues(bc.MultiSeries[i].Data)
+		if !ok {
+			continue
+		}
+
+		payload.Series[i].Values = encodeArray(values)
+		if positions != nil {
+			encoded := encodeArray(positions)
+			key := fmt.Sprint(*encoded)
+			index, ok := positionIndex[key]
+			if !ok {
+				index = len(payload.Positions)
+				positionIndex[key] = index
+				payload.Positions = append(payload.Positions, encoded)
+			}
+			payload.Series[i].Positions = index
+		}
+		bc.MultiSeries[i].Data = []any{}
+	}
+
+	if categories != nil {
+		payload.Categories = encodeCategories(axis, categories)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("compactChart: %w", err)
+	}
+	slog.Debug("compactChart", "series", len(payload.Series), "positions", len(payload.Positions), "bytes", len(data))
+
+	bc.AddJSFuncs(fmt.Sprintf(compactDecoder, data))
+	return nil
+}
@@ -0,0 +1,247 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ainvaltin/nu-plugin"
+)
+
+// Decodes an array like the decoder script does.
+func decodeArray(t *testing.T, a *compactArray) []float64 {
+	t.Helper()
+	res := make([]float64, a.Len)
+
+	if a.Type == "range" {
+		for i := range res {
+			res[i] = a.Offset + float64(float64(i)*a.Step)
+		}
+		return res
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(a.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum := a.Offset
+	for i := range res {
+		switch a.Type {
+		case "u8":
+			res[i] = (a.Offset + float64(raw[i])) / a.Scale
+			if raw[i] == math.MaxUint8 {
+				res[i] = math.NaN()
+			}
+		case "u16":
+			v := binary.LittleEndian.Uint16(raw[2*i:])
+			res[i] = (a.Offset + float64(v)) / a.Scale
+			if v == math.MaxUint16 {
+				res[i] = math.NaN()
+			}
+		case "u32":
+			v := binary.LittleEndian.Uint32(raw[4*i:])
+			res[i] = (a.Offset + float64(v)) / a.Scale
+			if v == math.MaxUint32 {
+				res[i] = math.NaN()
+			}
+		case "d32":
+			sum += float64(int32(binary.LittleEndian.Uint32(raw[4*i:])))
+			res[i] = sum / a.Scale
+		case "f32":
+			res[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(raw[4*i:])))
+		case "f64":
+			res[i] = math.Float64frombits(binary.LittleEndian.Uint64(raw[8*i:]))
+		default:
+			t.Fatalf("unknown array type %q", a.Type)
+		}
+	}
+	return res
+}
+
+// Compares floats exactly, NaN values are equal.
+func identicalFloats(got, want []float64) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] && !(math.IsNaN(got[i]) && math.IsNaN(want[i])) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestEncodeArray(t *testing.T) {
+	nan := math.NaN()
+	start := float64(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC).UnixMilli())
+
+	tests := []struct {
+		name     string
+		values   []float64
+		wantType string
+	}{
+		{"empty", []float64{}, "u8"},
+		{"single value", []float64{42}, "u8"},
+		{"constant", []float64{7, 7, 7}, "range"},
+		{"row index", []float64{0, 1, 2, 3, 4}, "range"},
+		{"regular timestamps", []float64{start, start + 60000, start + 120000}, "range"},
+		{"irregular timestamps", []float64{start, start + 1, start + 2e9, start + 4e9, start + 6e9}, "d32"},
+		{"small integers with gaps", []float64{-3, nan, 200, 17}, "u8"},
+		{"one decimal", []float64{10.2, 11.5, 9.8, 1000.1}, "u16"},
+		{"large integers", []float64{-2e9, 1e9, 0, nan}, "u32"},
+		{"wide range with gaps", []float64{0, nan, 1 << 40}, "f32"},
+		{"float32", []float64{0.5, math.Ldexp(1, 100), 0.25}, "f32"},
+		{"float64", []float64{math.Pi, math.E, 1e300}, "f64"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded := encodeArray(tt.values)
+			if encoded.Type != tt.wantType {
+				t.Errorf("got type %q, want %q", encoded.Type, tt.wantType)
+			}
+			if got := decodeArray(t, encoded); !identicalFloats(got, tt.values) {
+				t.Errorf("got %v, want %v", got, tt.values)
+			}
+		})
+	}
+}
+
+func TestEncodeCategories(t *testing.T) {
+	categories := []any{"b", "a", "b", int64(1), "a"}
+
+	encoded := encodeCategories("yAxis", categories)
+	if want := []any{"b", "a", int64(1)}; !reflect.DeepEqual(encoded.Values, want) {
+		t.Errorf("got dictionary %v, want %v", encoded.Values, want)
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(encoded.Indices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]any, len(raw)/4)
+	for i := range got {
+		got[i] = encoded.Values[binary.LittleEndian.Uint32(raw[4*i:])]
+	}
+	if !reflect.DeepEqual(got, categories) {
+		t.Errorf("got %v, want %v", got, categories)
+	}
+}
+
+// Returns a table with a value per minute and one decimal place, like the
+// output of a typical metrics export.
+func metricsTable(t *testing.T, rows int) *numericTable {
+	t.Helper()
+	start := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	input := make([]nu.Value, rows)
+	for i := range input {
+		value := math.Round((500+100*math.Sin(float64(i)/50))*10) / 10
+		input[i] = nu.Value{Value: nu.Record{
+			"time":  {Value: start.Add(time.Duration(i) * time.Minute)},
+			"value": {Value: value},
+		}}
+	}
+
+	table, err := readNumericTable(input, "time", time.UTC, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return table
+}
+
+func TestCompactChartSize(t *testing.T) {
+	const rows = 100000
+
+	render := func(compact bool) int {
+		line, _, err := buildLine(metricsTable(t, rows), &nu.ExecCommand{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if compact {
+			if err := compactChart(&line.BaseConfiguration, nil, "xAxis"); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		var buf bytes.Buffer
+		if err := line.Render(&buf); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Len()
+	}
+
+	plain, compact := render(false), render(true)
+	t.Logf("%d points: %d bytes as JSON, %d bytes compact", rows, plain, compact)
+	if compact*10 > plain {
+		t.Errorf("got %d compact bytes for %d bytes of JSON, want a tenth or less", compact, plain)
+	}
+}
+
+func TestCompactDecoder(t *testing.T) {
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node is needed to run the decoder script")
+	}
+
+	table := metricsTable(t, 500)
+	table.Columns["value"][3] = math.NaN()
+	line, _, err := buildLine(table, &nu.ExecCommand{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := json.Marshal(line.MultiSeries[0].Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := compactChart(&line.BaseConfiguration, []any{"a", "b", "a"}, "xAxis"); err != nil {
+		t.Fatal(err)
+	}
+	script := strings.ReplaceAll(string(line.JSFunctions.Fns[len(line.JSFunctions.Fns)-1]), "%MY_ECHARTS%", "chart")
+	script = "var chart = {setOption: function (o) { console.log(JSON.stringify(o)); }};" + script
+
+	out, err := exec.Command(node, "-e", script).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var option struct {
+		Series []struct {
+			Data []any `json:"data"`
+		} `json:"series"`
+		XAxis []struct {
+			Data []any `json:"data"`
+		} `json:"xAxis"`
+	}
+	if err := json.Unmarshal(out, &option); err != nil {
+		t.Fatal(err)
+	}
+
+	// The line data items of go-echarts are objects with a value.
+	var wantData []struct {
+		Value []any `json:"value"`
+	}
+	if err := json.Unmarshal(want, &wantData); err != nil {
+		t.Fatal(err)
+	}
+	if len(option.Series) == 0 || len(option.Series[0].Data) != len(wantData) {
+		t.Fatalf("got %d series, want the %d points of the first series", len(option.Series), len(wantData))
+	}
+	for i, point := range option.Series[0].Data {
+		if !reflect.DeepEqual(point, wantData[i].Value) {
+			t.Fatalf("got point %v at %d, want %v", point, i, wantData[i].Value)
+		}
+	}
+	if want := []any{"a", "b", "a"}; len(option.XAxis) != 1 || !reflect.DeepEqual(option.XAxis[0].Data, want) {
+		t.Errorf("got categories %v, want %v", option.XAxis, want)
+	}
+}
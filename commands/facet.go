@@ -0,0 +1,287 @@
+package commands
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"math"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/components"
+	"github.com/go-echarts/go-echarts/v2/opts"
+	charttypes "github.com/go-echarts/go-echarts/v2/types"
+
+	"github.com/ainvaltin/nu-plugin"
+
+	"github.com/gtnebel/nu_plugin_nuplot/commands/flags"
+)
+
+// Chart types that can be arranged in a facet grid.
+type facetChart interface {
+	components.Charter
+	SetGlobalOptions(options ...charts.GlobalOpts) *charts.RectChart
+}
+
+// Builder function that creates a chart from the input values without
+// rendering it. The range of the plotted values is returned along with the
+// chart, so that the facets can share the range of their value axes.
+type ChartBuilderFunc[C facetChart] = func(any, *nu.ExecCommand) (C, valueRange, error)
+
+// The range of the values that are plotted on the value axis of a chart. If
+// no values were added, Min is larger than Max.
+type valueRange struct {
+	Min float64
+	Max float64
+}
+
+// Returns an empty range.
+func newValueRange() valueRange {
+	return valueRange{Min: math.Inf(1), Max: math.Inf(-1)}
+}
+
+// Extends the range by the given values. Missing values are ignored.
+func (r *valueRange) add(values ...float64) {
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			r.Min = math.Min(r.Min, v)
+			r.Max = math.Max(r.Max, v)
+		}
+	}
+}
+
+// A group of input rows that share the same value in the facet column. The
+// rows are either kept as they are or, for the table based charts, read into
+// a [numericTable], see [handleTableInput].
+type facetGroup struct {
+	Name  string
+	Rows  []nu.Value
+	Table *numericTable
+}
+
+// Returns the input of the chart of the group.
+func (g facetGroup) input() any {
+	if g.Table != nil {
+		return g.Table
+	}
+	return g.Rows
+}
+
+// Extends the range by another range. Empty ranges are ignored, so that
+// facets without values do not widen the range to infinity.
+func (r *valueRange) merge(other valueRange) {
+	if other.Min <= other.Max {
+		r.add(other.Min, other.Max)
+	}
+}
+
+// Returns a global option that sets the range of the y-axis to the shared
+// range of all facets. Explicit ranges given by --ymin and --ymax are kept.
+func withSharedRange(shared valueRange) charts.GlobalOpts {
+	return withYAxisChange(func(yAxis *opts.YAxis) {
+		if yAxis.Min == nil {
+			yAxis.Min = shared.Min
+		}
+		if yAxis.Max == nil {
+			yAxis.Max = shared.Max
+		}
+	})
+}
+
+// Builds the charts of all groups and returns them along with the range of
+// the values that are plotted in all charts. The charts are built first, so
+// that the shared range covers the values as they are plotted, after
+// transforms and aggregation.
+func buildFacetCharts[C facetChart](groups []facetGroup, call *nu.ExecCommand, buildFunc ChartBuilderFunc[C]) ([]C, valueRange, error) {
+	built := make([]C, len(groups))
+	shared := newValueRange()
+	for i, group := range groups {
+		chart, plotted, err := buildFunc(group.input(), call)
+		if err != nil {
+			return nil, valueRange{}, fmt.Errorf("facet %q: %w", group.Name, err)
+		}
+		built[i] = chart
+		shared.merge(plotted)
+	}
+
+	return built, shared, nil
+}
+
+// Wraps a plot handler so that the --facet flag is supported. If the flag is
+// given, the input table is split by the facet column and one chart per group
+// is built with buildFunc. All charts are arranged on a single page.
+// Otherwise plotFunc is called as usual.
+func withFacets[C facetChart](plotFunc PlotHandlerFunc, buildFunc ChartBuilderFunc[C]) PlotHandlerFunc {
+	return func(input any, call *nu.ExecCommand) error {
+		facetName := getCellPathFlag(call, flags.Facet.Long, "")
+		if facetName == "" {
+			return plotFunc(input, call)
+		}
+
+		scales := getStringFlag(call, flags.FacetScales.Long, flags.FacetScales.Default.Value.(string))
+		if scales != "shared" && scales != "free" {
+			return fmt.Errorf("invalid --facet-scales %q, expected one of: shared, free", scales)
+		}
+		columns := max(int(getIntFlag(call, flags.FacetColumns.Long, 2)), 1)
+		slog.Debug("withFacets", "facet", facetName, "scales", scales, "columns", columns)
+
+		var groups []facetGroup
+		switch inputValue := input.(type) {
+		case []facetGroup:
+			if len(inputValue) == 0 {
+				return plotFunc(newTableReader(XAxisSeries, nil, nil, 0, nil).table, call)
+			}
+			groups = inputValue
+		case []nu.Value:
+			if len(inputValue) == 0 {
+				return plotFunc(input, call)
+			}
+
+			var err error
+			if groups, err = splitFacetGroups(inputValue, facetName); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("withFacets: --facet needs a table as input, got %T", input)
+		}
+
+		page := components.NewPage()
+		page.SetLayout(components.PageFlexLayout)
+		page.SetPageTitle(getStringFlag(call, flags.Title.Long, flags.Title.Default.Value.(string)))
+
+		built, shared, err := buildFacetCharts(groups, call, buildFunc)
+		if err != nil {
+			return err
+		}
+		slog.Debug("withFacets", "min", shared.Min, "max", shared.Max)
+
+		rows := (len(groups) + columns - 1) / columns
+		width := getIntFlag(call, flags.Width.Long, 1200) / int64(columns)
+		height := max(getIntFlag(call, flags.Height.Long, 600)/int64(rows), 300)
+
+		for i, group := range groups {
+			chart := built[i]
+
+			theme := getStringFlag(call, flags.ColorTheme.Long, charttypes.ThemeWesteros)
+			if !slices.Contains(Themes, theme) {
+				theme = charttypes.ThemeWesteros
+			}
+
+			chart.SetGlobalOptions(
+				charts.WithInitializationOpts(opts.Initialization{
+					Theme:  theme,
+					Width:  fmt.Sprintf("%dpx", width),
+					Height: fmt.Sprintf("%dpx", height),
+				}),
+				charts.WithTitleOpts(opts.Title{
+					Title:    group.Name,
+					Subtitle: fmt.Sprintf("%s = %s", facetName, group.Name),
+				}),
+			)
+
+			// Stacked or reversed bar charts have other value ranges or
+			// another value axis, so the range is left to echarts.
+			if scales == "shared" && shared.Min <= shared.Max &&
+				!getBoolFlag(call, flags.Stacked.Long) && !getBoolFlag(call, flags.XYReverse.Long) {
+				chart.SetGlobalOptions(withSharedRange(shared))
+			}
+
+			page.AddCharts(chart)
+		}
+
+		return renderChart(func(f *os.File) error { return page.Render(f) })
+	}
+}
+
+// Splits the rows of the input table into groups by the value of the facet
+// column. The groups keep the order in which their values first appear. The
+// facet column itself is removed from the rows.
+func splitFacetGroups(input []nu.Value, facetName string) ([]facetGroup, error) {
+	groups := make([]facetGroup, 0)
+
+	for itemIndex, item := range input {
+		record, ok := item.Value.(nu.Record)
+		if !ok {
+			return nil, fmt.Errorf("splitFacetGroups: --facet needs a table as input, got %T in row %d", item.Value, itemIndex)
+		}
+
+		name := "(none)"
+		if v, ok := record[facetName]; ok {
+			name = fmt.Sprint(v.Value)
+		}
+
+		row := make(nu.Record, len(record))
+		for k, v := range record {
+			if k != facetName {
+				row[k] = v
+			}
+		}
+
+		index := slices.IndexFunc(groups, func(g facetGroup) bool { return g.Name == name })
+		if index < 0 {
+			groups = append(groups, facetGroup{Name: name})
+			index = len(groups) - 1
+		}
+		groups[index].Rows = append(groups[index].Rows, nu.Value{Value: row})
+	}
+
+	return groups, nil
+}
+
+// Reads the rows of the input stream into one table per facet, like
+// [readNumericTableStream]. The groups keep the order in which their values
+// first appear. The facet column itself is not read into the tables.
+func readFacetTablesStream(input <-chan nu.Value, facetName string, xAxisName string, loc *time.Location, paths map[string]cellPath) ([]facetGroup, error) {
+	groups := make([]facetGroup, 0)
+	readers := make(map[string]*tableReader)
+
+	// The facet path is resolved here, so that the readers do not add it
+	// again after the facet column was removed.
+	facetPath, nested := paths[facetName]
+	rowPaths := maps.Clone(paths)
+	delete(rowPaths, facetName)
+
+	itemIndex := 0
+	for item := range input {
+		record, ok := item.Value.(nu.Record)
+		if !ok {
+			for range input {
+			}
+			return nil, fmt.Errorf("readFacetTablesStream: --facet needs a table as input, got %T in row %d", item.Value, itemIndex)
+		}
+
+		name := "(none)"
+		v, ok := record[facetName]
+		if !ok && nested {
+			var err error
+			if v, err = facetPath.follow(item); err != nil {
+				for range input {
+				}
+				return nil, fmt.Errorf("readFacetTablesStream: row %d: cell path %q: %w", itemIndex, facetName, err)
+			}
+			ok = true
+		}
+		if ok {
+			name = fmt.Sprint(v.Value)
+		}
+		delete(record, facetName)
+
+		reader, ok := readers[name]
+		if !ok {
+			reader = newTableReader(xAxisName, loc, rowPaths, 0, nil)
+			readers[name] = reader
+			groups = append(groups, facetGroup{Name: name, Table: reader.table})
+		}
+		if err := reader.add(item); err != nil {
+			for range input {
+			}
+			return nil, fmt.Errorf("readFacetTablesStream: facet %q: %w", name, err)
+		}
+		itemIndex++
+	}
+	slog.Debug("readFacetTablesStream", "rows", itemIndex, "facets", len(groups))
+
+	return groups, nil
+}
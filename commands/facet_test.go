@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+
+	"github.com/ainvaltin/nu-plugin"
+)
+
+func TestValueRange(t *testing.T) {
+	nan := math.NaN()
+
+	r := newValueRange()
+	if r.Min <= r.Max {
+		t.Fatalf("got range %v, want an empty range", r)
+	}
+
+	r.add(nan, 3, -1, nan)
+	r.merge(newValueRange())
+	r.merge(valueRange{Min: 2, Max: 7})
+	if want := (valueRange{Min: -1, Max: 7}); r != want {
+		t.Errorf("got range %v, want %v", r, want)
+	}
+}
+
+func TestSplitFacetGroups(t *testing.T) {
+	rows := records(
+		map[string]any{"host": "b", "cpu": int64(1)},
+		map[string]any{"host": "a", "cpu": int64(2)},
+		map[string]any{"host": "b", "cpu": int64(3)},
+	)
+
+	groups, err := splitFacetGroups(rows, "host")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := make([]string, len(groups))
+	sizes := make([]int, len(groups))
+	for i, group := range groups {
+		names[i] = group.Name
+		sizes[i] = len(group.Rows)
+	}
+	if want := []string{"b", "a"}; !slices.Equal(names, want) {
+		t.Errorf("got groups %v, want %v in order of appearance", names, want)
+	}
+	if want := []int{2, 1}; !slices.Equal(sizes, want) {
+		t.Errorf("got group sizes %v, want %v", sizes, want)
+	}
+}
+
+func TestBuildFacetChartsSharedRange(t *testing.T) {
+	nan := math.NaN()
+
+	groups := []facetGroup{
+		{Name: "a", Rows: nuValues(1.0, 5.0, 3.0)},
+		{Name: "b", Rows: nuValues(-2.0, nan, 0.5)},
+		// A facet with missing values only does not widen the range.
+		{Name: "c", Rows: nuValues(nan, nan)},
+	}
+	for i := range groups {
+		table, err := readNumericTable(groups[i].Rows, XAxisSeries, time.UTC, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		groups[i].Table = table
+	}
+
+	built, shared, err := buildFacetCharts(groups, &nu.ExecCommand{}, buildLine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(built) != len(groups) {
+		t.Fatalf("got %d charts, want %d", len(built), len(groups))
+	}
+	if want := (valueRange{Min: -2, Max: 5}); shared != want {
+		t.Errorf("got shared range %v, want %v", shared, want)
+	}
+
+	// Explicit ranges of the y-axis are kept.
+	built[0].SetGlobalOptions(withSharedRange(shared))
+	built[1].SetGlobalOptions(charts.WithYAxisOpts(opts.YAxis{Min: -10}), withSharedRange(shared))
+
+	if yAxis := built[0].YAxisList[0]; yAxis.Min != -2.0 || yAxis.Max != 5.0 {
+		t.Errorf("got y-axis range %v to %v, want -2 to 5", yAxis.Min, yAxis.Max)
+	}
+	if yAxis := built[1].YAxisList[0]; yAxis.Min != -10 || yAxis.Max != 5.0 {
+		t.Errorf("got y-axis range %v to %v, want -10 to 5", yAxis.Min, yAxis.Max)
+	}
+}
@@ -106,4 +106,544 @@ var (
 		VarId:    0,
 		Default:  nil,
 	}
+
+	Open = nu.Flag{
+		Long:     "open",
+		Short:    0,
+		Shape:    syntaxshape.CellPath(),
+		Required: false,
+		Desc:     "Only if input is a table: the column name which holds the open values",
+		VarId:    0,
+	}
+
+	Close = nu.Flag{
+		Long:     "close",
+		Short:    0,
+		Shape:    syntaxshape.CellPath(),
+		Required: false,
+		Desc:     "Only if input is a table: the column name which holds the close values",
+		VarId:    0,
+	}
+
+	Low = nu.Flag{
+		Long:     "low",
+		Short:    0,
+		Shape:    syntaxshape.CellPath(),
+		Required: false,
+		Desc:     "Only if input is a table: the column name which holds the low values",
+		VarId:    0,
+	}
+
+	High = nu.Flag{
+		Long:     "high",
+		Short:    0,
+		Shape:    syntaxshape.CellPath(),
+		Required: false,
+		Desc:     "Only if input is a table: the column name which holds the high values",
+		VarId:    0,
+	}
+
+	Volume = nu.Flag{
+		Long:     "volume",
+		Short:    0,
+		Shape:    syntaxshape.CellPath(),
+		Required: false,
+		Desc:     "Only if input is a table: the column name which holds the volume values. They are plotted as bar chart below the candles.",
+		VarId:    0,
+	}
+
+	MovingAverage = nu.Flag{
+		Long:     "ma",
+		Short:    0,
+		Shape:    syntaxshape.String(),
+		Required: false,
+		Desc:     "Comma separated list of moving average periods that are drawn over the candles, e.g. 5,20,60",
+		VarId:    0,
+	}
+
+	Bollinger = nu.Flag{
+		Long:     "bollinger",
+		Short:    0,
+		Shape:    syntaxshape.String(),
+		Required: false,
+		Desc:     "Period and standard deviation factor of the bollinger bands, e.g. 20,2",
+		VarId:    0,
+	}
+
+	RSI = nu.Flag{
+		Long:     "rsi",
+		Short:    0,
+		Shape:    syntaxshape.Int(),
+		Required: false,
+		Desc:     "Adds a relative strength index panel with the given period, e.g. 14",
+		VarId:    0,
+	}
+
+	MACD = nu.Flag{
+		Long:     "macd",
+		Short:    0,
+		Shape:    syntaxshape.String(),
+		Required: false,
+		Desc:     "Adds a MACD panel with the given fast, slow and signal periods, e.g. 12,26,9",
+		VarId:    0,
+	}
+
+	Error = nu.Flag{
+		Long:     "error",
+		Short:    0,
+		Shape:    syntaxshape.CellPath(),
+		Required: false,
+		Desc:     "Only if input is a table: the column name which holds the symmetric error of the plotted series",
+		VarId:    0,
+	}
+
+	Lower = nu.Flag{
+		Long:     "lower",
+		Short:    0,
+		Shape:    syntaxshape.CellPath(),
+		Required: false,
+		Desc:     "Only if input is a table: the column name which holds the lower bound of the plotted series",
+		VarId:    0,
+	}
+
+	Upper = nu.Flag{
+		Long:     "upper",
+		Short:    0,
+		Shape:    syntaxshape.CellPath(),
+		Required: false,
+		Desc:     "Only if input is a table: the column name which holds the upper bound of the plotted series",
+		VarId:    0,
+	}
+
+	ErrorStyle = nu.Flag{
+		Long:     "error-style",
+		Short:    0,
+		Shape:    syntaxshape.String(),
+		Required: false,
+		Desc:     "How errors and bounds are drawn. One of: band, bars",
+		VarId:    0,
+		Default:  &nu.Value{Value: "band"},
+	}
+
+	SeriesType = nu.Flag{
+		Long:     "series-type",
+		Short:    0,
+		Shape:    syntaxshape.Any(),
+		Required: false,
+		Desc:     "Record of columns and their series type, e.g. {latency: line}. Type is one of: bar, line. Other columns are plotted as bars.",
+		VarId:    0,
+	}
+
+	Y2 = nu.Flag{
+		Long:     "y2",
+		Short:    0,
+		Shape:    syntaxshape.String(),
+		Required: false,
+		Desc:     "Comma separated list of columns that are plotted on a second y-axis on the right side",
+		VarId:    0,
+	}
+
+	Value = nu.Flag{
+		Long:     "value",
+		Short:    0,
+		Shape:    syntaxshape.CellPath(),
+		Required: false,
+		Desc:     "Only if input is a table: the column name which holds the values",
+		VarId:    0,
+	}
+
+	Total = nu.Flag{
+		Long:     "total",
+		Short:    0,
+		Shape:    nil,
+		Required: false,
+		Desc:     "Adds a bar with the final total at the end of the chart.",
+		VarId:    0,
+		Default:  nil,
+	}
+
+	Subtotal = nu.Flag{
+		Long:     "subtotal",
+		Short:    0,
+		Shape:    syntaxshape.String(),
+		Required: false,
+		Desc:     "Comma separated list of labels. A bar with the running total is added after each of them.",
+		VarId:    0,
+	}
+
+	Task = nu.Flag{
+		Long:     "task",
+		Short:    0,
+		Shape:    syntaxshape.CellPath(),
+		Required: false,
+		Desc:     "Only if input is a table: the column name which holds the task names",
+		VarId:    0,
+	}
+
+	Start = nu.Flag{
+		Long:     "start",
+		Short:    0,
+		Shape:    syntaxshape.CellPath(),
+		Required: false,
+		Desc:     "Only if input is a table: the column name which holds the start times as datetime, date string or duration",
+		VarId:    0,
+	}
+
+	End = nu.Flag{
+		Long:     "end",
+		Short:    0,
+		Shape:    syntaxshape.CellPath(),
+		Required: false,
+		Desc:     "Only if input is a table: the column name which holds the end times as datetime, date string or duration",
+		VarId:    0,
+	}
+
+	Group = nu.Flag{
+		Long:     "group",
+		Short:    0,
+		Shape:    syntaxshape.CellPath(),
+		Required: false,
+		Desc:     "Only if input is a table: the column name which holds the lane of each task",
+		VarId:    0,
+	}
+
+	Status = nu.Flag{
+		Long:     "status",
+		Short:    0,
+		Shape:    syntaxshape.CellPath(),
+		Required: false,
+		Desc:     "Only if input is a table: the column name which holds a status that is used to color the bars",
+		VarId:    0,
+	}
+
+	Origin = nu.Flag{
+		Long:     "origin",
+		Short:    0,
+		Shape:    syntaxshape.String(),
+		Required: false,
+		Desc:     "Date string that durations in --start and --end are relative to. Without it, durations are plotted in seconds.",
+		VarId:    0,
+	}
+
+	Facet = nu.Flag{
+		Long:     "facet",
+		Short:    0,
+		Shape:    syntaxshape.CellPath(),
+		Required: false,
+		Desc:     "Only if input is a table: splits the table by the values of this column and plots one chart per group",
+		VarId:    0,
+	}
+
+	FacetScales = nu.Flag{
+		Long:     "facet-scales",
+		Short:    0,
+		Shape:    syntaxshape.String(),
+		Required: false,
+		Desc:     "Whether the facet charts share the y-axis range. One of: shared, free",
+		VarId:    0,
+		Default:  &nu.Value{Value: "shared"},
+	}
+
+	FacetColumns = nu.Flag{
+		Long:     "facet-columns",
+		Short:    0,
+		Shape:    syntaxshape.Int(),
+		Required: false,
+		Desc:     "Number of facet charts per row",
+		VarId:    0,
+		Default:  &nu.Value{Value: 2},
+	}
+
+	GroupBy = nu.Flag{
+		Long:     "group-by",
+		Short:    0,
+		Shape:    syntaxshape.CellPath(),
+		Required: false,
+		Desc:     "Only if input is a table in long format: each distinct value of this column becomes its own series",
+		VarId:    0,
+	}
+
+	Y = nu.Flag{
+		Long:     "y",
+		Short:    0,
+		Shape:    syntaxshape.String(),
+		Required: false,
+		Desc:     "Only if input is a table: comma separated list of the columns which hold the values, plotted in the given order. Without --y, the columns keep their input order only for raw CSV and JSON input, the columns of nushell tables are sorted by name",
+		VarId:    0,
+	}
+
+	Agg = nu.Flag{
+		Long:     "agg",
+		Short:    0,
+		Shape:    syntaxshape.String(),
+		Required: false,
+		Desc:     "Collapse rows with the same x value with one of: sum, mean, median, min, max, count, first, last",
+		VarId:    0,
+	}
+
+	Exclude = nu.Flag{
+		Long:     "exclude",
+		Short:    0,
+		Shape:    syntaxshape.String(),
+		Required: false,
+		Desc:     "Only if input is a table: comma separated list of the columns which are not plotted",
+		VarId:    0,
+	}
+
+	Missing = nu.Flag{
+		Long:     "missing",
+		Short:    0,
+		Shape:    syntaxshape.String(),
+		Required: false,
+		Desc:     "How missing values are plotted: gap, zero, interpolate or drop-row",
+		VarId:    0,
+		Default:  &nu.Value{Value: "gap"},
+	}
+
+	XFormat = nu.Flag{
+		Long:     "x-format",
+		Short:    0,
+		Shape:    syntaxshape.String(),
+		Required: false,
+		Desc:     "Format of the labels of a time x-axis, e.g. \"%Y-%m-%d %H:%M\" or \"{yyyy}-{MM}-{dd}\"",
+		VarId:    0,
+	}
+
+	Timezone = nu.Flag{
+		Long:     "timezone",
+		Short:    0,
+		Shape:    syntaxshape.String(),
+		Required: false,
+		Desc:     "Time zone of date strings without offset, e.g. \"UTC\", \"local\" or \"Europe/Berlin\"",
+		VarId:    0,
+	}
+
+	XLog = nu.Flag{
+		Long:     "xlog",
+		Short:    0,
+		Shape:    nil,
+		Required: false,
+		Desc:     "Use a logarithmic x-axis, only for numeric x values",
+		VarId:    0,
+	}
+
+	YLog = nu.Flag{
+		Long:     "ylog",
+		Short:    0,
+		Shape:    nil,
+		Required: false,
+		Desc:     "Use a logarithmic y-axis",
+		VarId:    0,
+	}
+
+	LogBase = nu.Flag{
+		Long:     "log-base",
+		Short:    0,
+		Shape:    syntaxshape.Number(),
+		Required: false,
+		Desc:     "Base of the logarithmic axes",
+		VarId:    0,
+		Default:  &nu.Value{Value: 10},
+	}
+
+	XMin = nu.Flag{
+		Long:     "xmin",
+		Short:    0,
+		Shape:    syntaxshape.String(),
+		Required: false,
+		Desc:     "Minimum of the x-axis, a number or a date",
+		VarId:    0,
+	}
+
+	XMax = nu.Flag{
+		Long:     "xmax",
+		Short:    0,
+		Shape:    syntaxshape.String(),
+		Required: false,
+		Desc:     "Maximum of the x-axis, a number or a date",
+		VarId:    0,
+	}
+
+	YMin = nu.Flag{
+		Long:     "ymin",
+		Short:    0,
+		Shape:    syntaxshape.Number(),
+		Required: false,
+		Desc:     "Minimum of the y-axis",
+		VarId:    0,
+	}
+
+	YMax = nu.Flag{
+		Long:     "ymax",
+		Short:    0,
+		Shape:    syntaxshape.Number(),
+		Required: false,
+		Desc:     "Maximum of the y-axis",
+		VarId:    0,
+	}
+
+	XName = nu.Flag{
+		Long:     "xname",
+		Short:    0,
+		Shape:    syntaxshape.String(),
+		Required: false,
+		Desc:     "Title of the x-axis",
+		VarId:    0,
+	}
+
+	YName = nu.Flag{
+		Long:     "yname",
+		Short:    0,
+		Shape:    syntaxshape.String(),
+		Required: false,
+		Desc:     "Title of the y-axis",
+		VarId:    0,
+	}
+
+	MaxPoints = nu.Flag{
+		Long:     "max-points",
+		Short:    0,
+		Shape:    syntaxshape.Int(),
+		Required: false,
+		Desc:     "Downsample the chart to at most this number of rows, which are shared by all series",
+		VarId:    0,
+	}
+
+	Compact = nu.Flag{
+		Long:     "compact",
+		Short:    0,
+		Shape:    nil,
+		Required: false,
+		Desc:     "Embed the data as compact binary arrays, that are decoded when the chart is loaded",
+		VarId:    0,
+	}
+
+	Label = nu.Flag{
+		Long:     "label",
+		Short:    0,
+		Shape:    syntaxshape.CellPath(),
+		Required: false,
+		Desc:     "Only if input is a table: the column name which holds the labels",
+		VarId:    0,
+	}
+
+	Transform = nu.Flag{
+		Long:     "transform",
+		Short:    0,
+		Shape:    syntaxshape.String(),
+		Required: false,
+		Desc:     "Transform the values of each series: cumsum, diff, rate, pct-change, normalize, index100 or zscore",
+		VarId:    0,
+	}
+
+	Style = nu.Flag{
+		Long:     "style",
+		Short:    0,
+		Shape:    syntaxshape.String(),
+		Required: false,
+		Desc:     "Style of the lines: smooth, straight, step-start, step-middle or step-end",
+		VarId:    0,
+		Default:  &nu.Value{Value: "smooth"},
+	}
+
+	Symbols = nu.Flag{
+		Long:     "symbols",
+		Short:    0,
+		Shape:    nil,
+		Required: false,
+		Desc:     "Show the markers of all data points, --symbols=false hides them",
+		VarId:    0,
+	}
+
+	LineWidth = nu.Flag{
+		Long:     "line-width",
+		Short:    0,
+		Shape:    syntaxshape.Number(),
+		Required: false,
+		Desc:     "Width of the lines in pixels",
+		VarId:    0,
+	}
+
+	Rolling = nu.Flag{
+		Long:     "rolling",
+		Short:    0,
+		Shape:    syntaxshape.Int(),
+		Required: false,
+		Desc:     "Add a dashed line with a rolling window over this number of values",
+		VarId:    0,
+	}
+
+	RollingFn = nu.Flag{
+		Long:     "rolling-fn",
+		Short:    0,
+		Shape:    syntaxshape.String(),
+		Required: false,
+		Desc:     "Function of the rolling window: mean, median or max",
+		VarId:    0,
+		Default:  &nu.Value{Value: "mean"},
+	}
+
+	Trend = nu.Flag{
+		Long:     "trend",
+		Short:    0,
+		Shape:    syntaxshape.String(),
+		Required: false,
+		Desc:     "Add a fitted trend to each series: linear, poly:N, exp, log or power",
+		VarId:    0,
+	}
+
+	TrendOnly = nu.Flag{
+		Long:     "trend-only",
+		Short:    0,
+		Shape:    nil,
+		Required: false,
+		Desc:     "Return the fitted trends as record instead of plotting them",
+		VarId:    0,
+	}
+
+	Forecast = nu.Flag{
+		Long:     "forecast",
+		Short:    0,
+		Shape:    syntaxshape.Int(),
+		Required: false,
+		Desc:     "Forecast this number of values after the last value of each series",
+		VarId:    0,
+	}
+
+	Season = nu.Flag{
+		Long:     "season",
+		Short:    0,
+		Shape:    syntaxshape.Int(),
+		Required: false,
+		Desc:     "Number of values of a season for Holt-Winters forecasts",
+		VarId:    0,
+	}
+
+	ForecastMethod = nu.Flag{
+		Long:     "forecast-method",
+		Short:    0,
+		Shape:    syntaxshape.String(),
+		Required: false,
+		Desc:     "Exponential smoothing of the forecast: simple, holt or holt-winters (default: holt, holt-winters with --season)",
+		VarId:    0,
+	}
+
+	Period = nu.Flag{
+		Long:     "period",
+		Short:    0,
+		Shape:    syntaxshape.Int(),
+		Required: true,
+		Desc:     "Number of values of a season, e.g. 7 for daily values with weekly seasons",
+		VarId:    0,
+	}
+
+	Method = nu.Flag{
+		Long:     "method",
+		Short:    0,
+		Shape:    syntaxshape.String(),
+		Required: false,
+		Desc:     "Method of the decomposition: moving-average or stl",
+		VarId:    0,
+		Default:  &nu.Value{Value: "moving-average"},
+	}
 )
@@ -0,0 +1,325 @@
+package commands
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/ainvaltin/nu-plugin"
+
+	"github.com/gtnebel/nu_plugin_nuplot/commands/flags"
+)
+
+// List of all available forecast methods
+var ForecastMethods = []string{"simple", "holt", "holt-winters"}
+
+// The smoothing parameters that are tried when a forecast model is fitted.
+var smoothingGrid = []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9}
+
+// z value of the 95% prediction interval
+const predictionZ = 1.96
+
+// The forecast of a series with the bounds of its prediction interval.
+type forecastResult struct {
+	Values []float64
+	Lower  []float64
+	Upper  []float64
+	// Standard deviation of the one-step errors of the fitted model
+	Sigma float64
+}
+
+// Runs exponential smoothing over the values and returns the sum of the
+// squared one-step errors, the number of errors and the forecast of the next
+// horizon values. Holt-Winters uses additive seasons of the given length.
+func exponentialSmoothing(y []float64, method string, season int, alpha, beta, gamma float64, horizon int) (float64, int, []float64) {
+	sse, count := 0.0, 0
+	res := make([]float64, horizon)
+
+	switch method {
+	case "simple":
+		level := y[0]
+		for _, v := range y[1:] {
+			e := v - level
+			sse, count = sse+e*e, count+1
+			level += alpha * e
+		}
+		for h := range res {
+			res[h] = level
+		}
+	case "holt":
+		level, trend := y[0], y[1]-y[0]
+		for _, v := range y[1:] {
+			e := v - (level + trend)
+			sse, count = sse+e*e, count+1
+			prev := level
+			level = alpha*v + (1-alpha)*(level+trend)
+			trend = beta*(level-prev) + (1-beta)*trend
+		}
+		for h := range res {
+			res[h] = level + float64(h+1)*trend
+		}
+	case "holt-winters":
+		mean := func(values []float64) float64 {
+			sum := 0.0
+			for _, v := range values {
+				sum += v
+			}
+			return sum / float64(len(values))
+		}
+
+		level := mean(y[:season])
+		trend := (mean(y[season:2*season]) - level) / float64(season)
+		seasonal := make([]float64, len(y))
+		for i := range season {
+			seasonal[i] = y[i] - level
+		}
+
+		for t := season; t < len(y); t++ {
+			e := y[t] - (level + trend + seasonal[t-season])
+			sse, count = sse+e*e, count+1
+			prev := level
+			level = alpha*(y[t]-seasonal[t-season]) + (1-alpha)*(level+trend)
+			trend = beta*(level-prev) + (1-beta)*trend
+			seasonal[t] = gamma*(y[t]-level) + (1-gamma)*seasonal[t-season]
+		}
+		for h := range res {
+			res[h] = level + float64(h+1)*trend + seasonal[len(y)-season+h%season]
+		}
+	}
+
+	return sse, count, res
+}
+
+// Returns the variance of the forecast h steps ahead relative to the variance
+// of the one-step errors. These are the formulas of the equivalent additive
+// state space models, see Hyndman & Athanasopoulos, Forecasting: Principles
+// and Practice, table 8.8. The smoothing parameters of the trend and the
+// seasons are converted to the parameters of the state space models first.
+func forecastVarianceFactor(method string, season int, alpha, beta, gamma float64, h int) float64 {
+	fh := float64(h)
+
+	switch method {
+	case "simple":
+		return 1 + alpha*alpha*(fh-1)
+	case "holt":
+		b := alpha * beta
+		return 1 + (fh-1)*(alpha*alpha+alpha*b*fh+b*b*fh*(2*fh-1)/6)
+	default:
+		b, g := alpha*beta, (1-alpha)*gamma
+		k, m := float64((h-1)/season), float64(season)
+		return 1 + (fh-1)*(alpha*alpha+alpha*b*fh+b*b*fh*(2*fh-1)/6) +
+			g*k*(2*alpha+g+b*m*(k+1))
+	}
+}
+
+// Fits the smoothing parameters of the method to the values by a grid search
+// and forecasts the next horizon values. The 95% prediction interval assumes
+// normally distributed errors, its width grows with the distance from the
+// last value as given by [forecastVarianceFactor].
+func forecastSeries(y []float64, method string, season, horizon int) (forecastResult, error) {
+	minValues := map[string]int{"simple": 2, "holt": 3, "holt-winters": 2*season + 1}[method]
+	if len(y) < minValues {
+		return forecastResult{}, fmt.Errorf("%d values are not enough for a %s forecast, at least %d are needed", len(y), method, minValues)
+	}
+
+	betas, gammas := []float64{0}, []float64{0}
+	if method != "simple" {
+		betas = smoothingGrid
+	}
+	if method == "holt-winters" {
+		gammas = smoothingGrid
+	}
+
+	bestSSE, bestCount := math.Inf(1), 0
+	var best, params []float64
+	for _, alpha := range smoothingGrid {
+		for _, beta := range betas {
+			for _, gamma := range gammas {
+				sse, count, values := exponentialSmoothing(y, method, season, alpha, beta, gamma, horizon)
+				if sse < bestSSE {
+					bestSSE, bestCount, best = sse, count, values
+					params = []float64{alpha, beta, gamma}
+					slog.Debug("forecastSeries", "alpha", alpha, "beta", beta, "gamma", gamma, "sse", sse)
+				}
+			}
+		}
+	}
+
+	sigma := math.Sqrt(bestSSE / float64(max(bestCount, 1)))
+	res := forecastResult{
+		Values: best,
+		Lower:  make([]float64, horizon),
+		Upper:  make([]float64, horizon),
+		Sigma:  sigma,
+	}
+	for h, v := range best {
+		factor := forecastVarianceFactor(method, season, params[0], params[1], params[2], h+1)
+		width := predictionZ * sigma * math.Sqrt(factor)
+		res.Lower[h] = v - width
+		res.Upper[h] = v + width
+	}
+
+	return res, nil
+}
+
+// Returns the median of the differences between neighbouring values.
+func medianStep(values []float64) float64 {
+	if len(values) < 2 {
+		return 1
+	}
+
+	steps := make([]float64, len(values)-1)
+	for i := range steps {
+		steps[i] = values[i+1] - values[i]
+	}
+	slices.Sort(steps)
+	return steps[len(steps)/2]
+}
+
+// Appends n rows to the table. The x values continue at the median interval
+// of the existing rows, x values that are neither numbers nor dates are
+// labeled with the distance to the last row. All columns are NaN in the new
+// rows.
+func (t *numericTable) extendRows(n int) {
+	if t.XAxisName != XAxisSeries {
+		switch t.X.Kind {
+		case xDates:
+			step := time.Duration(medianStep(t.timePositions())) * time.Millisecond
+			last := t.X.Dates[len(t.X.Dates)-1]
+			for i := range n {
+				t.X.Dates = append(t.X.Dates, last.Add(time.Duration(i+1)*step))
+			}
+		case xNumbers:
+			step := medianStep(t.X.Numbers)
+			last := t.X.Numbers[len(t.X.Numbers)-1]
+			for i := range n {
+				t.X.Numbers = append(t.X.Numbers, last+float64(i+1)*step)
+			}
+		default:
+			for i := range n {
+				t.X.append(fmt.Sprintf("+%d", i+1))
+			}
+		}
+	}
+
+	for k, c := range t.Columns {
+		for range n {
+			c = append(c, math.NaN())
+		}
+		t.Columns[k] = c
+	}
+	t.Rows += n
+}
+
+// The columns of the forecast of a series, see [numericTable.addForecastColumns].
+type forecastColumns struct {
+	Forecast string
+	Lower    string
+	Upper    string
+}
+
+// Forecasts each of the given columns by the number of rows given in the
+// --forecast flag and appends the rows to the table. The forecasts and the
+// bounds of their prediction intervals are stored in new columns, that are
+// returned by the names of the columns they belong to. The forecasts start
+// after the last row with a value of the series, missing values at the end
+// of the series are covered by the forecast. The forecast columns start with
+// the last value of the series, so that the lines are connected.
+func (t *numericTable) addForecastColumns(call *nu.ExecCommand, names []string) (map[string]forecastColumns, error) {
+	horizon := int(getIntFlag(call, flags.Forecast.Long, 0))
+	if horizon == 0 {
+		return nil, nil
+	}
+	if horizon < 0 {
+		return nil, flagError(call, flags.Forecast, fmt.Errorf("the number of values to forecast has to be positive, got %d", horizon))
+	}
+
+	season := int(getIntFlag(call, flags.Season.Long, 0))
+	if season < 0 || season == 1 {
+		return nil, flagError(call, flags.Season, fmt.Errorf("a season needs at least 2 values, got %d", season))
+	}
+
+	method := getStringFlag(call, flags.ForecastMethod.Long, "")
+	switch {
+	case method == "" && season > 0:
+		method = "holt-winters"
+	case method == "":
+		method = "holt"
+	case !slices.Contains(ForecastMethods, method):
+		return nil, flagError(call, flags.ForecastMethod, fmt.Errorf("invalid method %q, expected one of: %v", method, ForecastMethods))
+	case method == "holt-winters" && season == 0:
+		return nil, flagError(call, flags.ForecastMethod, fmt.Errorf("holt-winters needs the length of a season, use --%s", flags.Season.Long))
+	}
+	slog.Debug("addForecastColumns", "horizon", horizon, "method", method, "season", season)
+
+	forecasts := make(map[string]forecastResult, len(names))
+	lastRows := make(map[string]int, len(names))
+	rows := t.Rows
+	for _, name := range names {
+		// Missing values are left out, they should be handled by --missing.
+		values := slices.DeleteFunc(slices.Clone(t.Columns[name]), math.IsNaN)
+		if len(values) == 0 {
+			continue
+		}
+
+		forecast, err := forecastSeries(values, method, season, horizon)
+		if err != nil {
+			return nil, fmt.Errorf("series %q: %w", name, err)
+		}
+		if forecast.Sigma == 0 {
+			slog.Warn("The forecast fits the series exactly, the prediction interval is empty", "series", name)
+		}
+		forecasts[name] = forecast
+
+		last := t.Rows - 1
+		for math.IsNaN(t.Columns[name][last]) {
+			last--
+		}
+		lastRows[name] = last
+		rows = max(rows, last+1+horizon)
+	}
+
+	if len(forecasts) == 0 {
+		return nil, nil
+	}
+
+	t.extendRows(rows - t.Rows)
+
+	res := make(map[string]forecastColumns, len(forecasts))
+	for _, name := range names {
+		forecast, ok := forecasts[name]
+		if !ok {
+			continue
+		}
+
+		columns := forecastColumns{
+			Forecast: name + " forecast",
+			Lower:    name + " forecast lower",
+			Upper:    name + " forecast upper",
+		}
+		for _, c := range []struct {
+			name   string
+			values []float64
+		}{
+			{columns.Forecast, forecast.Values},
+			{columns.Lower, forecast.Lower},
+			{columns.Upper, forecast.Upper},
+		} {
+			column := make([]float64, t.Rows)
+			for i := range column {
+				column[i] = math.NaN()
+			}
+			last := lastRows[name]
+			column[last] = t.Columns[name][last]
+			copy(column[last+1:], c.values)
+
+			t.Columns[c.name] = column
+			t.Kinds[c.name] = t.Kinds[name]
+		}
+		res[name] = columns
+	}
+
+	return res, nil
+}
@@ -0,0 +1,151 @@
+package commands
+
+import (
+	"math"
+	"testing"
+)
+
+// Checks that the values are equal up to rounding errors.
+func equalFloats(got, want []float64) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if math.IsNaN(want[i]) != math.IsNaN(got[i]) || math.Abs(got[i]-want[i]) > 1e-9 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestExponentialSmoothing(t *testing.T) {
+	seasons := []float64{3, -1, -2}
+
+	tests := []struct {
+		name   string
+		y      []float64
+		method string
+		season int
+		want   []float64
+	}{
+		{
+			name:   "simple constant",
+			y:      []float64{5, 5, 5, 5},
+			method: "simple",
+			want:   []float64{5, 5, 5},
+		},
+		{
+			name:   "holt linear",
+			y:      []float64{1, 3, 5, 7, 9},
+			method: "holt",
+			want:   []float64{11, 13, 15},
+		},
+		{
+			name:   "holt-winters seasons",
+			y:      append(append(append([]float64{}, seasons...), seasons...), seasons...),
+			method: "holt-winters",
+			season: 3,
+			want:   []float64{3, -1, -2, 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sse, count, got := exponentialSmoothing(tt.y, tt.method, tt.season, 0.5, 0.5, 0.5, len(tt.want))
+			if !equalFloats(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			if sse > 1e-18 {
+				t.Errorf("got sse %v, want 0", sse)
+			}
+			if count == 0 {
+				t.Errorf("got no errors")
+			}
+		})
+	}
+}
+
+func TestForecastSeries(t *testing.T) {
+	// A linear series with alternating noise
+	y := make([]float64, 30)
+	for i := range y {
+		y[i] = 10 + 0.5*float64(i) + float64(i%2*2-1)
+	}
+
+	for _, method := range []string{"simple", "holt", "holt-winters"} {
+		t.Run(method, func(t *testing.T) {
+			forecast, err := forecastSeries(y, method, 4, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(forecast.Values) != 10 || len(forecast.Lower) != 10 || len(forecast.Upper) != 10 {
+				t.Fatalf("got %d values, want 10", len(forecast.Values))
+			}
+			if forecast.Sigma <= 0 {
+				t.Errorf("got sigma %v, want a positive value", forecast.Sigma)
+			}
+
+			// The interval is symmetric and does not get narrower.
+			width := 0.0
+			for h, v := range forecast.Values {
+				upper, lower := forecast.Upper[h]-v, v-forecast.Lower[h]
+				if math.Abs(upper-lower) > 1e-9 || upper < width {
+					t.Errorf("step %d: got interval %v..%v around %v", h, forecast.Lower[h], forecast.Upper[h], v)
+				}
+				width = upper
+			}
+			if want := predictionZ * forecast.Sigma; math.Abs(forecast.Upper[0]-forecast.Values[0]-want) > 1e-9 {
+				t.Errorf("got first interval width %v, want %v", forecast.Upper[0]-forecast.Values[0], want)
+			}
+		})
+	}
+}
+
+func TestForecastSeriesExact(t *testing.T) {
+	forecast, err := forecastSeries([]float64{2, 4, 6, 8, 10}, "holt", 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalFloats(forecast.Values, []float64{12, 14}) || forecast.Sigma != 0 {
+		t.Errorf("got %v with sigma %v, want [12 14] with sigma 0", forecast.Values, forecast.Sigma)
+	}
+	if !equalFloats(forecast.Lower, forecast.Values) || !equalFloats(forecast.Upper, forecast.Values) {
+		t.Errorf("got interval %v..%v, want an empty interval", forecast.Lower, forecast.Upper)
+	}
+}
+
+func TestForecastSeriesTooShort(t *testing.T) {
+	if _, err := forecastSeries([]float64{1, 2, 3, 4, 5}, "holt-winters", 3, 2); err == nil {
+		t.Error("got no error for less than two seasons and one value")
+	}
+}
+
+func TestForecastVarianceFactor(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		alpha  float64
+		beta   float64
+		gamma  float64
+		h      int
+		want   float64
+	}{
+		{"one step", "holt-winters", 0.3, 0.2, 0.1, 1, 1},
+		{"random walk", "simple", 1, 0, 0, 5, 5},
+		{"simple", "simple", 0.5, 0, 0, 3, 1.5},
+		// b = 0.25: 1 + 2·(0.25 + 0.5·0.25·3 + 0.0625·3·5/6)
+		{"holt", "holt", 0.5, 0.5, 0, 3, 2.5625},
+		// Without seasons holt-winters is the same as holt.
+		{"holt-winters without seasons", "holt-winters", 0.5, 0.5, 0, 3, 2.5625},
+		// k = 1, g = 0.25: 2.5625 + 0.25·(1 + 0.25 + 0.25·2·2)
+		{"holt-winters", "holt-winters", 0.5, 0.5, 0.5, 3, 3.125},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := forecastVarianceFactor(tt.method, 2, tt.alpha, tt.beta, tt.gamma, tt.h); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+
+	"github.com/ainvaltin/nu-plugin"
+	"github.com/ainvaltin/nu-plugin/types"
+
+	"github.com/gtnebel/nu_plugin_nuplot/commands/flags"
+)
+
+// Default name of the gantt series if neither --status nor --group is given.
+const DefaultGanttSeries = "Tasks"
+
+// Javascript function that draws a horizontal bar for each data item of a
+// custom series. The data items hold [lane index, start, end].
+const ganttRenderItem = `function (params, api) {
+	var lane = api.value(0);
+	var start = api.coord([api.value(1), lane]);
+	var end = api.coord([api.value(2), lane]);
+	var height = api.size([0, 1])[1] * 0.6;
+	var rect = echarts.graphic.clipRectByRect(
+		{ x: start[0], y: start[1] - height / 2, width: end[0] - start[0], height: height },
+		{ x: params.coordSys.x, y: params.coordSys.y, width: params.coordSys.width, height: params.coordSys.height }
+	);
+	return rect && { type: 'rect', transition: ['shape'], shape: rect, style: api.style() };
+}`
+
+// This function initializes the nuplot gantt command.
+func NuplotGantt() *nu.Command {
+	return &nu.Command{
+		Signature: nu.PluginSignature{
+			Name:        "nuplot gantt",
+			Category:    "Chart",
+			Desc:        "Plots a gantt chart / timeline of tasks.",
+			Description: "Each row of the input table is drawn as horizontal bar from its --start to its --end time. Start and end values can be datetimes, date strings or durations relative to --origin. Tasks can be put into lanes with --group and colored by --status. The bars span a time axis and the lanes a category axis, so there are no logarithmic axes.",
+			SearchTerms: []string{"plot", "graph", "gantt", "timeline"},
+			Named: []nu.Flag{
+				flags.Task,
+				flags.Start,
+				flags.End,
+				flags.Group,
+				flags.Status,
+				flags.Origin,
+				flags.XName,
+				flags.YName,
+				flags.XMin,
+				flags.XMax,
+				flags.YMin,
+				flags.YMax,
+				flags.Title,
+				flags.SubTitle,
+				flags.Width,
+				flags.Height,
+				flags.ColorTheme,
+				flags.Verbose,
+			},
+			InputOutputTypes: []nu.InOutTypes{
+				{In: types.Table(types.RecordDef{}), Out: types.Nothing()},
+				{In: types.String(), Out: types.Nothing()},
+				{In: types.Binary(), Out: types.Nothing()},
+			},
+			AllowMissingExamples: true,
+		},
+		Examples: []nu.Example{
+			{
+				Description: `Plot the timeline of a deployment.`,
+				Example:     `[[task start end status]; [build 2024-06-01T10:00:00Z 2024-06-01T10:12:00Z ok] [test 2024-06-01T10:12:00Z 2024-06-01T10:40:00Z failed] [deploy 2024-06-01T10:41:00Z 2024-06-01T10:45:00Z ok]] | nuplot gantt --task task --start start --end end --status status`,
+			},
+			{
+				Description: `Plot CI job durations relative to the pipeline start, one lane per stage.`,
+				Example:     `[[job stage start end]; [lint check 0sec 40sec] [unit check 0sec 3min] [image build 3min 7min]] | nuplot gantt --task job --group stage --start start --end end`,
+			},
+		},
+		OnRun: nuplotGanttHandler,
+	}
+}
+
+func nuplotGanttHandler(ctx context.Context, call *nu.ExecCommand) error {
+	checkVerboseFlag(call)
+	return handleCommandInput(call, plotGantt)
+}
+
+// Converts a start or end value of a task to a position on the x-axis. Dates
+// are converted to milliseconds since epoch. Durations are added to the origin
+// or, in relative mode, converted to seconds.
+func ganttPosition(value nu.Value, origin *time.Time, relative bool) (float64, error) {
+	switch v := matchXValue(value).(type) {
+	case time.Time:
+		if relative {
+			return 0, fmt.Errorf("dates can not be mixed with durations, use --origin")
+		}
+		return float64(v.UnixMilli()), nil
+	case time.Duration:
+		if relative {
+			return v.Seconds(), nil
+		}
+		if origin == nil {
+			return 0, fmt.Errorf("durations can not be mixed with dates, use --origin")
+		}
+		return float64(origin.Add(v).UnixMilli()), nil
+	default:
+		return 0, fmt.Errorf("unsupported time value: %v (%T)", v, v)
+	}
+}
+
+func plotGantt(input any, call *nu.ExecCommand) error {
+	taskName := getCellPathFlag(call, flags.Task.Long, "")
+	startName := getCellPathFlag(call, flags.Start.Long, "")
+	endName := getCellPathFlag(call, flags.End.Long, "")
+	groupName := getCellPathFlag(call, flags.Group.Long, "")
+	statusName := getCellPathFlag(call, flags.Status.Long, "")
+	slog.Debug("plotGantt", "task", taskName, "start", startName, "end", endName, "group", groupName, "status", statusName)
+
+	if taskName == "" || startName == "" || endName == "" {
+		return fmt.Errorf("plotGantt: the flags --task, --start and --end are required")
+	}
+
+	var origin *time.Time
+	if originFlag := getStringFlag(call, flags.Origin.Long, ""); originFlag != "" {
+		t, ok := matchXValue(nu.Value{Value: originFlag}).(time.Time)
+		if !ok {
+			return fmt.Errorf("plotGantt: --origin %q is not a valid date", originFlag)
+		}
+		origin = &t
+	}
+
+	// Lanes are the categories of the y-axis, series group the bars by color.
+	// Both keep the order in which their values appear in the input.
+	lanes := make([]string, 0)
+	seriesNames := make([]string, 0)
+	series := make(map[string][]opts.CustomData)
+	relative := false
+
+	switch inputValue := input.(type) {
+	case []nu.Value:
+		for itemIndex, item := range inputValue {
+			record, ok := item.Value.(nu.Record)
+			if !ok {
+				return fmt.Errorf("plotGantt: unsupported input value type: %T", item.Value)
+			}
+
+			// Durations without origin switch the chart into relative mode.
+			if itemIndex == 0 && origin == nil {
+				_, relative = record[startName].Value.(time.Duration)
+			}
+
+			start, err := ganttPosition(record[startName], origin, relative)
+			if err != nil {
+				return fmt.Errorf("plotGantt: column %q in row %d: %w", startName, itemIndex, err)
+			}
+			end, err := ganttPosition(record[endName], origin, relative)
+			if err != nil {
+				return fmt.Errorf("plotGantt: column %q in row %d: %w", endName, itemIndex, err)
+			}
+
+			task := fmt.Sprint(record[taskName].Value)
+			lane := task
+			if groupName != "" {
+				lane = fmt.Sprint(record[groupName].Value)
+			}
+
+			sName := DefaultGanttSeries
+			switch {
+			case statusName != "":
+				sName = fmt.Sprint(record[statusName].Value)
+			case groupName != "":
+				sName = lane
+			}
+
+			laneIndex := slices.Index(lanes, lane)
+			if laneIndex < 0 {
+				lanes = append(lanes, lane)
+				laneIndex = len(lanes) - 1
+			}
+
+			if !slices.Contains(seriesNames, sName) {
+				seriesNames = append(seriesNames, sName)
+			}
+
+			items := getSeries(series, sName)
+			series[sName] = append(items, opts.CustomData{
+				Name:  task,
+				Value: []float64{float64(laneIndex), start, end},
+			})
+		}
+	default:
+		return fmt.Errorf("plotGantt: unsupported input value type: %T", inputValue)
+	}
+
+	// create a new custom chart instance
+	gantt := charts.NewCustom()
+
+	gantt.SetGlobalOptions(buildGlobalChartOptions(call)...)
+
+	xAxis := opts.XAxis{Type: "time"}
+	if relative {
+		xAxis = opts.XAxis{Type: "value", Name: "seconds", Scale: opts.Bool(true)}
+	}
+	gantt.SetGlobalOptions(
+		charts.WithXAxisOpts(xAxis),
+		charts.WithYAxisOpts(opts.YAxis{
+			Type:    "category",
+			Data:    lanes,
+			Inverse: opts.Bool(true),
+		}),
+		charts.WithTooltipOpts(opts.Tooltip{Trigger: "item"}),
+	)
+
+	axisOpts, err := buildAxisOptions(call)
+	if err != nil {
+		return err
+	}
+	gantt.SetGlobalOptions(axisOpts...)
+
+	for _, sName := range seriesNames {
+		slog.Debug("plotGantt: Adding items to series", "series", sName, "items", len(series[sName]))
+		gantt.AddSeries(sName, series[sName],
+			charts.WithCustomChartOpts(opts.CustomChart{
+				RenderItem: opts.FuncOpts(ganttRenderItem),
+			}),
+			charts.WithEncodeOpts(opts.Encode{X: []int{1, 2}, Y: 0}),
+		)
+	}
+
+	setPageTitle(call, &gantt.BaseConfiguration)
+
+	return renderChart(func(f *os.File) error { return gantt.Render(f) })
+}
@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ainvaltin/nu-plugin"
+)
+
+func TestGanttPosition(t *testing.T) {
+	start := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	ms := func(t time.Time) float64 { return float64(t.UnixMilli()) }
+
+	tests := []struct {
+		name     string
+		value    any
+		origin   *time.Time
+		relative bool
+		want     float64
+		wantErr  bool
+	}{
+		{"date", start, nil, false, ms(start), false},
+		{"ISO 8601 string", "2024-06-01T10:00:00Z", nil, false, ms(start), false},
+		{"ISO 8601 string with offset", "2024-06-01T12:30:00+02:00", nil, false, ms(start.Add(30 * time.Minute)), false},
+		{"nushell date string", "2024-06-01 10:00:00 +00:00", nil, false, ms(start), false},
+		{"duration after origin", 90 * time.Second, &start, false, ms(start.Add(90 * time.Second)), false},
+		{"date with origin", start, &start, false, ms(start), false},
+		{"relative duration", 1500 * time.Millisecond, nil, true, 1.5, false},
+		{"duration without origin", time.Minute, nil, false, 0, true},
+		{"date in relative mode", start, nil, true, 0, true},
+		{"invalid string", "yesterday", nil, false, 0, true},
+		{"number", int64(5), nil, false, 0, true},
+		{"missing value", nil, nil, false, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ganttPosition(nu.Value{Value: tt.value}, tt.origin, tt.relative)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
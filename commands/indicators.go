@@ -0,0 +1,232 @@
+package commands
+
+import (
+	"math"
+
+	"github.com/go-echarts/go-echarts/v2/opts"
+	"github.com/montanaflynn/stats"
+)
+
+// Simple moving average over the given period. The first period-1 values are
+// undefined and set to NaN.
+func movingAverage(values []float64, period int) []float64 {
+	res := make([]float64, len(values))
+	sum := 0.0
+
+	for i, v := range values {
+		sum += v
+		if i >= period {
+			sum -= values[i-period]
+		}
+
+		if i+1 < period {
+			res[i] = math.NaN()
+		} else {
+			res[i] = sum / float64(period)
+		}
+	}
+
+	return res
+}
+
+// Applies the function to a rolling window over the given number of values.
+// Missing values in the window are ignored. The first window-1 values are
+// undefined and set to NaN.
+func rollingWindow(values []float64, window int, fn func(stats.Float64Data) (float64, error)) []float64 {
+	res := make([]float64, len(values))
+	buf := make([]float64, 0, window)
+
+	for i := range values {
+		res[i] = math.NaN()
+		if i+1 < window {
+			continue
+		}
+
+		buf = buf[:0]
+		for _, v := range values[i+1-window : i+1] {
+			if !math.IsNaN(v) {
+				buf = append(buf, v)
+			}
+		}
+		if len(buf) > 0 {
+			if v, err := fn(buf); err == nil {
+				res[i] = v
+			}
+		}
+	}
+
+	return res
+}
+
+// Exponential moving average over the given period. The average is seeded
+// with the first value, so all values are defined.
+func exponentialMovingAverage(values []float64, period int) []float64 {
+	res := make([]float64, len(values))
+	alpha := 2 / (float64(period) + 1)
+
+	for i, v := range values {
+		if i == 0 {
+			res[i] = v
+		} else {
+			res[i] = alpha*v + (1-alpha)*res[i-1]
+		}
+	}
+
+	return res
+}
+
+// Bollinger bands with the moving average over period as middle band and the
+// upper and lower bands k standard deviations away from it.
+func bollingerBands(values []float64, period int, k float64) (middle, upper, lower []float64) {
+	middle = movingAverage(values, period)
+	upper = make([]float64, len(values))
+	lower = make([]float64, len(values))
+
+	for i := range values {
+		if math.IsNaN(middle[i]) {
+			upper[i] = math.NaN()
+			lower[i] = math.NaN()
+			continue
+		}
+
+		variance := 0.0
+		for _, v := range values[i+1-period : i+1] {
+			variance += (v - middle[i]) * (v - middle[i])
+		}
+		deviation := math.Sqrt(variance / float64(period))
+
+		upper[i] = middle[i] + k*deviation
+		lower[i] = middle[i] - k*deviation
+	}
+
+	return
+}
+
+// Relative strength index using Wilder's smoothing. The first period values
+// are undefined and set to NaN.
+func relativeStrengthIndex(values []float64, period int) []float64 {
+	res := make([]float64, len(values))
+	avgGain, avgLoss := 0.0, 0.0
+
+	for i := range values {
+		if i == 0 {
+			res[i] = math.NaN()
+			continue
+		}
+
+		change := values[i] - values[i-1]
+		gain, loss := math.Max(change, 0), math.Max(-change, 0)
+
+		if i <= period {
+			avgGain += gain / float64(period)
+			avgLoss += loss / float64(period)
+		} else {
+			avgGain = (avgGain*float64(period-1) + gain) / float64(period)
+			avgLoss = (avgLoss*float64(period-1) + loss) / float64(period)
+		}
+
+		switch {
+		case i < period:
+			res[i] = math.NaN()
+		case avgLoss == 0:
+			res[i] = 100
+		default:
+			res[i] = 100 - 100/(1+avgGain/avgLoss)
+		}
+	}
+
+	return res
+}
+
+// Moving average convergence/divergence. Returns the MACD line, the signal
+// line and the histogram (difference of both).
+func movingAverageConvergenceDivergence(values []float64, fast, slow, signal int) (macd, signalLine, histogram []float64) {
+	fastEMA := exponentialMovingAverage(values, fast)
+	slowEMA := exponentialMovingAverage(values, slow)
+
+	macd = make([]float64, len(values))
+	for i := range values {
+		macd[i] = fastEMA[i] - slowEMA[i]
+	}
+
+	signalLine = exponentialMovingAverage(macd, signal)
+
+	histogram = make([]float64, len(values))
+	for i := range values {
+		histogram[i] = macd[i] - signalLine[i]
+	}
+
+	return
+}
+
+// Converts a list of floats to line chart data points. NaN values are
+// converted to the echarts placeholder for empty values.
+func float64ToLineData(values []float64) LineDataList {
+	res := make(LineDataList, len(values))
+
+	for i, v := range values {
+		if math.IsNaN(v) {
+			res[i] = opts.LineData{Value: "-"}
+		} else {
+			res[i] = opts.LineData{Value: v}
+		}
+	}
+
+	return res
+}
+
+// Converts a list of floats to bar chart data points. NaN values are
+// converted to the echarts placeholder for empty values.
+func float64ToBarData(values []float64) BarDataList {
+	res := make(BarDataList, len(values))
+
+	for i, v := range values {
+		if math.IsNaN(v) {
+			res[i] = opts.BarData{Value: "-"}
+		} else {
+			res[i] = opts.BarData{Value: v}
+		}
+	}
+
+	return res
+}
+
+// Converts a list of floats to line chart data points that hold their position
+// on the x-axis, as needed for time axes. If no positions are given, the result
+// is the same as of [float64ToLineData].
+func float64ToLineDataAt(positions []float64, values []float64) LineDataList {
+	if positions == nil {
+		return float64ToLineData(values)
+	}
+
+	res := make(LineDataList, len(values))
+	for i, v := range values {
+		if math.IsNaN(v) {
+			res[i] = opts.LineData{Value: []any{positions[i], "-"}}
+		} else {
+			res[i] = opts.LineData{Value: []any{positions[i], v}}
+		}
+	}
+
+	return res
+}
+
+// Converts a list of floats to bar chart data points that hold their position
+// on the x-axis, as needed for time axes. If no positions are given, the result
+// is the same as of [float64ToBarData].
+func float64ToBarDataAt(positions []float64, values []float64) BarDataList {
+	if positions == nil {
+		return float64ToBarData(values)
+	}
+
+	res := make(BarDataList, len(values))
+	for i, v := range values {
+		if math.IsNaN(v) {
+			res[i] = opts.BarData{Value: []any{positions[i], "-"}}
+		} else {
+			res[i] = opts.BarData{Value: []any{positions[i], v}}
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,147 @@
+package commands
+
+import (
+	"math"
+	"testing"
+
+	"github.com/montanaflynn/stats"
+)
+
+func TestMovingAverage(t *testing.T) {
+	nan := math.NaN()
+
+	tests := []struct {
+		name   string
+		values []float64
+		period int
+		want   []float64
+	}{
+		{"period 3", []float64{1, 2, 3, 4, 5}, 3, []float64{nan, nan, 2, 3, 4}},
+		{"period 1", []float64{1, 5, 2}, 1, []float64{1, 5, 2}},
+		{"period above length", []float64{1, 2}, 3, []float64{nan, nan}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := movingAverage(tt.values, tt.period); !equalFloats(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRollingWindow(t *testing.T) {
+	nan := math.NaN()
+
+	tests := []struct {
+		name   string
+		values []float64
+		window int
+		fn     func(stats.Float64Data) (float64, error)
+		want   []float64
+	}{
+		{"mean", []float64{1, 3, 5, 7}, 2, stats.Mean, []float64{nan, 2, 4, 6}},
+		{"mean with gaps", []float64{1, nan, 3, 5}, 2, stats.Mean, []float64{nan, 1, 3, 4}},
+		{"only gaps", []float64{nan, nan, 3}, 2, stats.Max, []float64{nan, nan, 3}},
+		{"median", []float64{5, 1, 3, 9}, 3, stats.Median, []float64{nan, nan, 3, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rollingWindow(tt.values, tt.window, tt.fn); !equalFloats(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBollingerBands(t *testing.T) {
+	nan := math.NaN()
+	// Standard deviation of 1, 2, 3 and of all other windows
+	deviation := math.Sqrt(2.0 / 3.0)
+
+	middle, upper, lower := bollingerBands([]float64{1, 2, 3, 4, 5}, 3, 2)
+
+	if want := []float64{nan, nan, 2, 3, 4}; !equalFloats(middle, want) {
+		t.Errorf("got middle band %v, want %v", middle, want)
+	}
+	if want := []float64{nan, nan, 2 + 2*deviation, 3 + 2*deviation, 4 + 2*deviation}; !equalFloats(upper, want) {
+		t.Errorf("got upper band %v, want %v", upper, want)
+	}
+	if want := []float64{nan, nan, 2 - 2*deviation, 3 - 2*deviation, 4 - 2*deviation}; !equalFloats(lower, want) {
+		t.Errorf("got lower band %v, want %v", lower, want)
+	}
+}
+
+func TestRelativeStrengthIndex(t *testing.T) {
+	nan := math.NaN()
+
+	tests := []struct {
+		name   string
+		values []float64
+		period int
+		want   []float64
+	}{
+		{"wilder smoothing", []float64{1, 2, 3, 2, 3, 4}, 2, []float64{nan, nan, 100, 50, 75, 87.5}},
+		{"only losses", []float64{5, 4, 3, 2}, 2, []float64{nan, nan, 0, 0}},
+		{"too short", []float64{1, 2}, 3, []float64{nan, nan}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := relativeStrengthIndex(tt.values, tt.period); !equalFloats(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMovingAverageConvergenceDivergence(t *testing.T) {
+	tests := []struct {
+		name      string
+		values    []float64
+		fast      int
+		slow      int
+		signal    int
+		macd      []float64
+		signals   []float64
+		histogram []float64
+	}{
+		{
+			name:      "constant",
+			values:    []float64{4, 4, 4},
+			fast:      12,
+			slow:      26,
+			signal:    9,
+			macd:      []float64{0, 0, 0},
+			signals:   []float64{0, 0, 0},
+			histogram: []float64{0, 0, 0},
+		},
+		{
+			// The fast average follows the values, the slow one has alpha 0.5.
+			name:      "rising",
+			values:    []float64{1, 2, 4},
+			fast:      1,
+			slow:      3,
+			signal:    3,
+			macd:      []float64{0, 0.5, 1.25},
+			signals:   []float64{0, 0.25, 0.75},
+			histogram: []float64{0, 0.25, 0.5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			macd, signal, histogram := movingAverageConvergenceDivergence(tt.values, tt.fast, tt.slow, tt.signal)
+			if !equalFloats(macd, tt.macd) {
+				t.Errorf("got macd %v, want %v", macd, tt.macd)
+			}
+			if !equalFloats(signal, tt.signals) {
+				t.Errorf("got signal %v, want %v", signal, tt.signals)
+			}
+			if !equalFloats(histogram, tt.histogram) {
+				t.Errorf("got histogram %v, want %v", histogram, tt.histogram)
+			}
+		})
+	}
+}
@@ -4,7 +4,11 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"math"
 	"os"
+	"slices"
+	"strconv"
+	"time"
 
 	"github.com/go-echarts/go-echarts/v2/charts"
 	"github.com/go-echarts/go-echarts/v2/opts"
@@ -30,11 +34,30 @@ func NuplotKline() *nu.Command {
 			Name:        "nuplot kline",
 			Category:    "Chart",
 			Desc:        "Plots a kline chart",
-			Description: "Title, size and color theme can be configured by flags. Each column that contains numbers will be plottet. The X axis can be set by means of the --xaxis flag.",
+			Description: "Title, size and color theme can be configured by flags. Each column that contains numbers will be plottet. The X axis can be set by means of the --xaxis flag. The candles are placed on a category axis, so only the y-axis can be logarithmic.",
 			SearchTerms: []string{"plot", "graph", "kline"},
 			// OptionalPositional: nu.PositionalArgs{},
 			Named: []nu.Flag{
 				flags.XAxis,
+				flags.Y,
+				flags.Exclude,
+				flags.Open,
+				flags.Close,
+				flags.Low,
+				flags.High,
+				flags.Volume,
+				flags.MovingAverage,
+				flags.Bollinger,
+				flags.RSI,
+				flags.MACD,
+				flags.XName,
+				flags.YName,
+				flags.XMin,
+				flags.XMax,
+				flags.YMin,
+				flags.YMax,
+				flags.YLog,
+				flags.LogBase,
 				flags.Title,
 				flags.SubTitle,
 				flags.Width,
@@ -44,6 +67,9 @@ func NuplotKline() *nu.Command {
 				flags.Verbose,
 			},
 			InputOutputTypes: []nu.InOutTypes{
+				{In: types.Table(types.RecordDef{}), Out: types.Nothing()},
+				{In: types.String(), Out: types.Nothing()},
+				{In: types.Binary(), Out: types.Nothing()},
 				{In: types.List(types.Table(types.RecordDef{})), Out: types.Nothing()},
 				{In: types.List(types.List(types.Number())), Out: types.Nothing()},
 			},
@@ -58,6 +84,14 @@ func NuplotKline() *nu.Command {
 			// 	Description: `Plot a kline graph of a table with addidional date column.`,
 			// 	Example:     `[[Date First Last Min Max]; ['2024-06-01' 1.53 1.64 1.50 1.66] ['2024-06-02' 1.63 1.73 1.61 1.75] ['2024-06-03' 1.72 1.57 1.52 1.77]] | nuplot kline --xaxis Date --fitted --title 'Fuel prices in June 24'`,
 			// },
+			{
+				Description: `Plot a kline graph from a table that already holds OHLC columns along with a volume sub-chart.`,
+				Example:     `[[date open close low high volume]; ['2024-06-03' 1.53 1.64 1.50 1.66 1200] ['2024-06-04' 1.63 1.73 1.61 1.75 900] ['2024-06-05' 1.72 1.57 1.52 1.77 1500]] | nuplot kline --xaxis date --open open --close close --low low --high high --volume volume --fitted`,
+			},
+			{
+				Description: `Draw moving averages and bollinger bands over the candles and add a RSI panel.`,
+				Example:     `open prices.csv | nuplot kline --xaxis date --open open --close close --low low --high high --ma 5,20 --bollinger 20,2 --rsi 14`,
+			},
 			{
 				Description: `Load data from a file and display kline chart.`,
 				Example:     `http get https://bulk.meteostat.net/v2/hourly/2023/10577.csv.gz | gunzip | from csv --noheaders | select column0 column2 | rename date temp | upsert date {|l| $l.date | into datetime | format date "%B"} | chunk-by {$in.date} | nuplot kline --xaxis date`,
@@ -116,6 +150,10 @@ func klineReadInputListItem(listItem []nu.Value, klineSeries KlineDataSeries, xA
 			// items := getSeries(seriesHelper, DefaultSeries)
 			items := getSeries(series, DefaultSeries)
 			series[DefaultSeries] = append(items, itemValue)
+		case nu.Filesize, time.Duration:
+			items := getSeries(series, DefaultSeries)
+			v, _ := ValueToFloat64(item)
+			series[DefaultSeries] = append(items, v)
 		case nu.Record:
 			// Try to set xAxisName to one of the columns in the record.
 			if itemIndex == 0 {
@@ -178,15 +216,402 @@ func klineReadInputListItem(listItem []nu.Value, klineSeries KlineDataSeries, xA
 	return
 }
 
+// Column names of a table that already holds the open, close, low and high
+// values of each candle.
+type klineColumns struct {
+	Open   string
+	Close  string
+	Low    string
+	High   string
+	Volume string
+}
+
+// Reads the OHLC column flags from the call. The returned bool is true, if
+// the OHLC columns are given on the command line.
+func getKlineColumns(call *nu.ExecCommand) (klineColumns, bool, error) {
+	columns := klineColumns{
+		Open:   getCellPathFlag(call, flags.Open.Long, ""),
+		Close:  getCellPathFlag(call, flags.Close.Long, ""),
+		Low:    getCellPathFlag(call, flags.Low.Long, ""),
+		High:   getCellPathFlag(call, flags.High.Long, ""),
+		Volume: getCellPathFlag(call, flags.Volume.Long, ""),
+	}
+
+	given := 0
+	for _, c := range []string{columns.Open, columns.Close, columns.Low, columns.High} {
+		if c != "" {
+			given += 1
+		}
+	}
+
+	switch {
+	case given == 4:
+		return columns, true, nil
+	case given > 0:
+		return columns, false, fmt.Errorf("the flags --open, --close, --low and --high have to be given together")
+	case columns.Volume != "":
+		return columns, false, fmt.Errorf("the flag --volume can only be used together with --open, --close, --low and --high")
+	default:
+		return columns, false, nil
+	}
+}
+
+// Reads a table in which every row holds the open, close, low and high values
+// of one candle. The values are taken as-is. Rows with a null or missing value
+// become gaps, just like missing volume values. If no x values are found,
+// xValues is nil.
+func klineReadInputTable(table []nu.Value, columns klineColumns, xAxisName string) (candles KlineDataList, volume BarDataList, xValues []any, res error) {
+	for rowIndex, row := range table {
+		record, ok := row.Value.(nu.Record)
+		if !ok {
+			res = fmt.Errorf("klineReadInputTable: unsupported input value type: %T", row.Value)
+			return
+		}
+
+		ohlc := [4]float64{}
+		missing := false
+		for i, column := range []string{columns.Open, columns.Close, columns.Low, columns.High} {
+			cell, ok := record[column]
+			if !ok || cell.Value == nil {
+				missing = true
+				continue
+			}
+			value, err := ValueToFloat64(cell)
+			if err != nil {
+				res = fmt.Errorf("klineReadInputTable: column %q in row %d: %w", column, rowIndex, err)
+				return
+			}
+			ohlc[i] = value
+		}
+		if missing {
+			// A candle with a missing value is drawn as a gap.
+			candles = append(candles, opts.KlineData{Value: "-"})
+		} else {
+			candles = append(candles, opts.KlineData{Value: ohlc})
+		}
+
+		if columns.Volume != "" {
+			if value, err := ValueToFloat64(record[columns.Volume]); err == nil {
+				volume = append(volume, opts.BarData{Value: value})
+			} else {
+				// "-" is the placeholder for an empty value in echarts.
+				volume = append(volume, opts.BarData{Value: "-"})
+			}
+		}
+
+		// If a xaxis is defined, fill the series with the values.
+		if xAxisName != XAxisSeries {
+			if v, ok := record[xAxisName]; ok {
+				xValues = append(xValues, matchXValue(v))
+			} else {
+				slog.Warn("Specified x-axis is not continuous. Reseting x-axis to default value.")
+				xAxisName = XAxisSeries
+				xValues = nil
+			}
+		}
+	}
+
+	return
+}
+
+// A sub-chart that is plotted in its own grid below the candles. The build
+// function has to put all series on the x- and y-axis with the given index.
+type klinePanel struct {
+	name  string
+	build func(axisIndex int) charts.Overlaper
+}
+
+// Returns the grid of the candles followed by one grid per panel below it.
+// The panels shrink as their number grows, so that the candle grid keeps at
+// least minMainHeight percent of the chart height.
+func klineGrids(panels int) []opts.Grid {
+	const top, bottom, panelHeight, gap, minMainHeight = 10.0, 88.0, 12.0, 5.0, 40.0
+
+	percent := func(v float64) string {
+		return strconv.FormatFloat(math.Round(v*10)/10, 'f', -1, 64) + "%"
+	}
+
+	mainHeight := bottom - top
+	slot, slotGap := panelHeight+gap, gap
+	if panels > 0 {
+		mainHeight = max(bottom-top-float64(panels)*(panelHeight+gap), minMainHeight)
+		slot = math.Floor((bottom-top-mainHeight)/float64(panels)*10) / 10
+		slotGap = math.Round(slot*gap/(panelHeight+gap)*10) / 10
+	}
+
+	grids := []opts.Grid{
+		{Left: "8%", Right: "8%", Top: percent(top), Height: percent(mainHeight)},
+	}
+	for i := range panels {
+		grids = append(grids, opts.Grid{
+			Left:   "8%",
+			Right:  "8%",
+			Top:    percent(top + mainHeight + slotGap + float64(i)*slot),
+			Height: percent(slot - slotGap),
+		})
+	}
+
+	return grids
+}
+
+// Lays out the candle grid and one grid per panel below it. All grids share
+// the same x-axis values and are zoomed together.
+func addKlinePanels(kline *charts.Kline, xData any, panels []klinePanel) {
+	grids := klineGrids(len(panels))
+	xAxisIndex := []int{0}
+
+	for i, panel := range panels {
+		axisIndex := i + 1
+
+		kline.ExtendXAxis(opts.XAxis{
+			Type:      "category",
+			GridIndex: axisIndex,
+			Data:      xData,
+			AxisLabel: &opts.AxisLabel{Show: opts.Bool(false)},
+		})
+		kline.ExtendYAxis(opts.YAxis{
+			Name:        panel.name,
+			GridIndex:   axisIndex,
+			SplitNumber: 2,
+			Scale:       opts.Bool(true),
+		})
+		kline.Overlap(panel.build(axisIndex))
+
+		xAxisIndex = append(xAxisIndex, axisIndex)
+	}
+
+	kline.SetGlobalOptions(
+		charts.WithGridOpts(grids...),
+		charts.WithAxisPointerOpts(&opts.AxisPointer{
+			Link: []opts.AxisPointerLink{{XAxisIndex: xAxisIndex}},
+		}),
+	)
+
+	// The data zoom components created in buildGlobalChartOptions only refer
+	// to the first x-axis. Link them to the panel grids as well.
+	for i := range kline.DataZoomList {
+		kline.DataZoomList[i].XAxisIndex = xAxisIndex
+	}
+}
+
+// Builds the panel that shows the volume values as bar chart.
+func klineVolumePanel(name string, volume BarDataList) klinePanel {
+	return klinePanel{
+		name: name,
+		build: func(axisIndex int) charts.Overlaper {
+			bar := charts.NewBar()
+			bar.AddSeries(name, volume, charts.WithBarChartOpts(opts.BarChart{
+				XAxisIndex: axisIndex,
+				YAxisIndex: axisIndex,
+			}))
+			return bar
+		},
+	}
+}
+
+// Extracts the close values of a list of candles. Gaps take the close value
+// of the candle before them, or of the first candle at the start, so that the
+// indicators continue over the gaps.
+func klineCloseValues(candles KlineDataList) []float64 {
+	res := make([]float64, len(candles))
+	leading := 0
+
+	for i, candle := range candles {
+		switch ohlc, ok := candle.Value.([4]float64); {
+		case ok:
+			res[i] = ohlc[1]
+		case i > leading:
+			res[i] = res[i-1]
+		default:
+			leading++
+		}
+	}
+
+	if leading < len(res) {
+		for i := range leading {
+			res[i] = res[leading]
+		}
+	}
+
+	return res
+}
+
+// Technical indicators that are computed from the close values of the
+// candles.
+type klineIndicators struct {
+	movingAverages []int
+	bollinger      []float64
+	rsi            int
+	macd           []int
+}
+
+// Reads the indicator flags from the call and validates them.
+func getKlineIndicators(call *nu.ExecCommand) (klineIndicators, error) {
+	var indicators klineIndicators
+	var err error
+
+	if indicators.movingAverages, err = getIntListFlag(call, flags.MovingAverage.Long); err != nil {
+		return indicators, err
+	}
+	for _, period := range indicators.movingAverages {
+		if period < 1 {
+			return indicators, fmt.Errorf("moving average periods have to be positive: %d", period)
+		}
+	}
+
+	if indicators.bollinger, err = getFloatListFlag(call, flags.Bollinger.Long); err != nil {
+		return indicators, err
+	}
+	if indicators.bollinger != nil && (len(indicators.bollinger) != 2 || indicators.bollinger[0] < 1) {
+		return indicators, fmt.Errorf("--bollinger expects a period and a factor, e.g. 20,2")
+	}
+
+	indicators.rsi = int(getIntFlag(call, flags.RSI.Long, 0))
+	if indicators.rsi < 0 {
+		return indicators, fmt.Errorf("the RSI period has to be positive: %d", indicators.rsi)
+	}
+
+	if indicators.macd, err = getIntListFlag(call, flags.MACD.Long); err != nil {
+		return indicators, err
+	}
+	if indicators.macd != nil && (len(indicators.macd) != 3 || slices.Min(indicators.macd) < 1) {
+		return indicators, fmt.Errorf("--macd expects a fast, slow and signal period, e.g. 12,26,9")
+	}
+
+	return indicators, nil
+}
+
+// Draws the moving averages and bollinger bands over the candles of a series.
+func addKlineOverlays(kline *charts.Kline, prefix string, closeValues []float64, indicators klineIndicators) {
+	if indicators.movingAverages == nil && indicators.bollinger == nil {
+		return
+	}
+
+	line := charts.NewLine()
+	lineOpts := charts.WithLineChartOpts(opts.LineChart{
+		Smooth:     opts.Bool(true),
+		ShowSymbol: opts.Bool(false),
+	})
+
+	for _, period := range indicators.movingAverages {
+		name := fmt.Sprintf("%sMA%d", prefix, period)
+		line.AddSeries(name, float64ToLineData(movingAverage(closeValues, period)), lineOpts)
+	}
+
+	if indicators.bollinger != nil {
+		period := int(indicators.bollinger[0])
+		middle, upper, lower := bollingerBands(closeValues, period, indicators.bollinger[1])
+		name := fmt.Sprintf("%sBOLL%d", prefix, period)
+		dashed := charts.WithLineStyleOpts(opts.LineStyle{Type: "dashed"})
+
+		line.AddSeries(name, float64ToLineData(middle), lineOpts)
+		line.AddSeries(name+" upper", float64ToLineData(upper), lineOpts, dashed)
+		line.AddSeries(name+" lower", float64ToLineData(lower), lineOpts, dashed)
+	}
+
+	kline.Overlap(line)
+}
+
+// Builds the panels for the RSI and MACD indicators of a series.
+func klineIndicatorPanels(prefix string, closeValues []float64, indicators klineIndicators) []klinePanel {
+	panels := make([]klinePanel, 0)
+
+	if indicators.rsi > 0 {
+		name := fmt.Sprintf("%sRSI%d", prefix, indicators.rsi)
+		rsi := float64ToLineData(relativeStrengthIndex(closeValues, indicators.rsi))
+
+		panels = append(panels, klinePanel{
+			name: name,
+			build: func(axisIndex int) charts.Overlaper {
+				line := charts.NewLine()
+				line.AddSeries(name, rsi, charts.WithLineChartOpts(opts.LineChart{
+					XAxisIndex: axisIndex,
+					YAxisIndex: axisIndex,
+					ShowSymbol: opts.Bool(false),
+				}))
+				return line
+			},
+		})
+	}
+
+	if indicators.macd != nil {
+		name := fmt.Sprintf("%sMACD", prefix)
+		macd, signal, histogram := movingAverageConvergenceDivergence(
+			closeValues, indicators.macd[0], indicators.macd[1], indicators.macd[2],
+		)
+
+		panels = append(panels, klinePanel{
+			name: name,
+			build: func(axisIndex int) charts.Overlaper {
+				lineOpts := charts.WithLineChartOpts(opts.LineChart{
+					XAxisIndex: axisIndex,
+					YAxisIndex: axisIndex,
+					ShowSymbol: opts.Bool(false),
+				})
+
+				bar := charts.NewBar()
+				bar.AddSeries(name+" histogram", float64ToBarData(histogram), charts.WithBarChartOpts(opts.BarChart{
+					XAxisIndex: axisIndex,
+					YAxisIndex: axisIndex,
+				}))
+
+				line := charts.NewLine()
+				line.AddSeries(name, float64ToLineData(macd), lineOpts)
+				line.AddSeries(name+" signal", float64ToLineData(signal), lineOpts)
+				bar.Overlap(line)
+
+				return bar
+			},
+		})
+	}
+
+	return panels
+}
+
 func plotKline(input any, call *nu.ExecCommand) error {
 	series := make(KlineDataSeries)
 	var xSeries []any = nil
+	var volume BarDataList = nil
 
 	xAxisName := getCellPathFlag(call, "xaxis", XAxisSeries)
 	slog.Debug("plotKline", "xAxisName", xAxisName)
 
+	columns, ohlcGiven, err := getKlineColumns(call)
+	if err != nil {
+		return err
+	}
+	slog.Debug("plotKline", "columns", columns, "ohlcGiven", ohlcGiven)
+
+	indicators, err := getKlineIndicators(call)
+	if err != nil {
+		return err
+	}
+	slog.Debug("plotKline", "indicators", indicators)
+
 	switch inputValue := input.(type) {
 	case []nu.Value:
+		if ohlcGiven {
+			// Try to set xAxisName to one of the columns in the table.
+			if len(inputValue) > 0 {
+				if record, ok := inputValue[0].Value.(nu.Record); ok {
+					xAxisName = autoSetXaxis(record, xAxisName)
+				}
+			}
+
+			candles, vol, xValues, err := klineReadInputTable(inputValue, columns, xAxisName)
+			if err != nil {
+				return err
+			}
+			if xValues == nil {
+				xAxisName = XAxisSeries
+			}
+
+			series[DefaultSeries] = candles
+			volume = vol
+			xSeries = xValues
+			break
+		}
+
 		xValue, err := klineReadInputListItem(inputValue, series, xAxisName)
 		if err == nil {
 			switch items := xValue.(type) {
@@ -205,27 +630,73 @@ func plotKline(input any, call *nu.ExecCommand) error {
 
 	kline.SetGlobalOptions(buildGlobalChartOptions(call)...)
 
-	// Put data into instance
-	itemCount := 0
-	for sName, sValues := range series {
-		if sName == xAxisName {
-			continue
+	axisOpts, err := buildAxisOptions(call)
+	if err != nil {
+		return err
+	}
+	kline.SetGlobalOptions(axisOpts...)
+
+	seriesNames, err := selectSeries(series, call, xAxisName)
+	if err != nil {
+		return fmt.Errorf("plotKline: %w", err)
+	}
+
+	// The low values are the smallest values of the candles.
+	for _, sName := range seriesNames {
+		lows := make([]float64, 0, len(series[sName]))
+		for _, candle := range series[sName] {
+			if ohlc, ok := candle.Value.([4]float64); ok {
+				lows = append(lows, ohlc[2])
+			}
+		}
+		if err := checkLogAxisValues(call, flags.YLog, lows); err != nil {
+			return err
 		}
+	}
 
+	// Put data into instance
+	itemCount := 0
+	for _, sName := range seriesNames {
+		sValues := series[sName]
 		itemCount = len(sValues)
 		slog.Debug("plotKline: Adding items to series", "series", sName, "items", itemCount)
 		kline = kline.AddSeries(sName, sValues)
 	}
 
+	var xData any
 	if xAxisName != XAxisSeries {
-		kline = kline.SetXAxis(xSeries)
+		xData = xSeries
 	} else {
 		xRange := make([]int, itemCount)
 		for i := range itemCount {
 			xRange[i] = i
 		}
 
-		kline = kline.SetXAxis(xRange)
+		xData = xRange
+	}
+	kline = kline.SetXAxis(xData)
+
+	panels := make([]klinePanel, 0)
+	if volume != nil {
+		panels = append(panels, klineVolumePanel(columns.Volume, volume))
+	}
+
+	// Indicators are computed for every candle series. Their names are only
+	// prefixed with the series name, if there is more than one series.
+	for _, sName := range seriesNames {
+		sValues := series[sName]
+		prefix := ""
+		if len(seriesNames) > 1 {
+			prefix = sName + " "
+		}
+
+		closeValues := klineCloseValues(sValues)
+		addKlineOverlays(kline, prefix, closeValues, indicators)
+		panels = append(panels, klineIndicatorPanels(prefix, closeValues, indicators)...)
+	}
+
+	if len(panels) > 0 {
+		addKlinePanels(kline, xData, panels)
 	}
 
 	setPageTitle(call, &kline.BaseConfiguration)
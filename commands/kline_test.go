@@ -0,0 +1,131 @@
+package commands
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/go-echarts/go-echarts/v2/opts"
+)
+
+// Parses a percentage of a grid option.
+func parsePercent(t *testing.T, s string) float64 {
+	t.Helper()
+	var v float64
+	if _, err := fmt.Sscanf(s, "%g%%", &v); err != nil {
+		t.Fatalf("invalid percentage %q: %v", s, err)
+	}
+	return v
+}
+
+func TestKlineGrids(t *testing.T) {
+	tests := []struct {
+		name       string
+		panels     int
+		mainHeight float64
+	}{
+		{"no panels", 0, 78},
+		{"one panel", 1, 61},
+		{"two panels", 2, 44},
+		{"volume, rsi and macd of two series", 5, 40},
+		{"many panels", 12, 40},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grids := klineGrids(tt.panels)
+			if len(grids) != tt.panels+1 {
+				t.Fatalf("got %d grids, want %d", len(grids), tt.panels+1)
+			}
+			if got := parsePercent(t, grids[0].Height); got != tt.mainHeight {
+				t.Errorf("got candle grid height %v%%, want %v%%", got, tt.mainHeight)
+			}
+
+			// The grids follow each other without overlapping and end above
+			// the data zoom slider.
+			end := 0.0
+			for i, grid := range grids {
+				top, height := parsePercent(t, grid.Top), parsePercent(t, grid.Height)
+				if height <= 0 {
+					t.Errorf("got height %v%% for grid %d", height, i)
+				}
+				if top < end-1e-9 {
+					t.Errorf("grid %d starts at %v%% before the end of the previous grid at %v%%", i, top, end)
+				}
+				end = top + height
+			}
+			if end > 88+1e-9 {
+				t.Errorf("the grids end at %v%%, want at most 88%%", end)
+			}
+		})
+	}
+}
+
+func TestKlineReadInputTable(t *testing.T) {
+	columns := klineColumns{Open: "o", Close: "c", Low: "l", High: "h", Volume: "v"}
+	table := records(
+		map[string]any{"o": 1.0, "c": 2.0, "l": 0.5, "h": 2.5, "v": int64(10)},
+		map[string]any{"o": 2.0, "c": nil, "l": 1.5, "h": 3.0, "v": int64(20)},
+		map[string]any{"o": 3.0, "l": 2.5, "h": 4.0},
+		map[string]any{"o": 4.0, "c": 5.0, "l": 3.5, "h": 5.5, "v": nil},
+	)
+
+	candles, volume, _, err := klineReadInputTable(table, columns, XAxisSeries)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantCandles := KlineDataList{
+		{Value: [4]float64{1, 2, 0.5, 2.5}},
+		{Value: "-"},
+		{Value: "-"},
+		{Value: [4]float64{4, 5, 3.5, 5.5}},
+	}
+	if !reflect.DeepEqual(candles, wantCandles) {
+		t.Errorf("got candles %v, want %v", candles, wantCandles)
+	}
+
+	wantVolume := BarDataList{{Value: 10.0}, {Value: 20.0}, {Value: "-"}, {Value: "-"}}
+	if !reflect.DeepEqual(volume, wantVolume) {
+		t.Errorf("got volume %v, want %v", volume, wantVolume)
+	}
+
+	// The indicators continue over the gaps.
+	if got, want := klineCloseValues(candles), []float64{2, 2, 2, 5}; !equalFloats(got, want) {
+		t.Errorf("got close values %v, want %v", got, want)
+	}
+}
+
+func TestKlineReadInputTableInvalidValue(t *testing.T) {
+	columns := klineColumns{Open: "o", Close: "c", Low: "l", High: "h"}
+	table := records(map[string]any{"o": 1.0, "c": "high", "l": 0.5, "h": 2.5})
+
+	if _, _, _, err := klineReadInputTable(table, columns, XAxisSeries); err == nil {
+		t.Error("got no error, want an error")
+	}
+}
+
+func TestKlineCloseValues(t *testing.T) {
+	gap := opts.KlineData{Value: "-"}
+	candle := func(close float64) opts.KlineData {
+		return opts.KlineData{Value: [4]float64{0, close, 0, 0}}
+	}
+
+	tests := []struct {
+		name    string
+		candles KlineDataList
+		want    []float64
+	}{
+		{"leading gaps", KlineDataList{gap, gap, candle(3), candle(4)}, []float64{3, 3, 3, 4}},
+		{"trailing gaps", KlineDataList{candle(1), gap, gap}, []float64{1, 1, 1}},
+		{"only gaps", KlineDataList{gap, gap}, []float64{0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := klineCloseValues(tt.candles); !equalFloats(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,9 +2,13 @@ package commands
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
+	"math"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/go-echarts/go-echarts/v2/charts"
 	"github.com/go-echarts/go-echarts/v2/opts"
@@ -33,6 +37,42 @@ func NuplotLine() *nu.Command {
 			// OptionalPositional: nu.PositionalArgs{},
 			Named: []nu.Flag{
 				flags.XAxis,
+				flags.GroupBy,
+				flags.Y,
+				flags.Exclude,
+				flags.Agg,
+				flags.Missing,
+				flags.Transform,
+				flags.Style,
+				flags.Symbols,
+				flags.LineWidth,
+				flags.Rolling,
+				flags.RollingFn,
+				flags.Trend,
+				flags.TrendOnly,
+				flags.Forecast,
+				flags.Season,
+				flags.ForecastMethod,
+				flags.XFormat,
+				flags.Timezone,
+				flags.Facet,
+				flags.FacetScales,
+				flags.FacetColumns,
+				flags.Error,
+				flags.Lower,
+				flags.Upper,
+				flags.ErrorStyle,
+				flags.XName,
+				flags.YName,
+				flags.XMin,
+				flags.XMax,
+				flags.YMin,
+				flags.YMax,
+				flags.XLog,
+				flags.YLog,
+				flags.LogBase,
+				flags.MaxPoints,
+				flags.Compact,
 				flags.Title,
 				flags.SubTitle,
 				flags.Width,
@@ -43,6 +83,10 @@ func NuplotLine() *nu.Command {
 			},
 			InputOutputTypes: []nu.InOutTypes{
 				{In: types.Table(types.RecordDef{}), Out: types.Nothing()},
+				{In: types.Table(types.RecordDef{}), Out: types.Record(types.RecordDef{})},
+				{In: types.Record(types.RecordDef{}), Out: types.Nothing()},
+				{In: types.String(), Out: types.Nothing()},
+				{In: types.Binary(), Out: types.Nothing()},
 				// {In: types.List(types.Table(types.RecordDef{})), Out: types.Nothing()},
 				{In: types.List(types.Number()), Out: types.Nothing()},
 			},
@@ -54,6 +98,74 @@ func NuplotLine() *nu.Command {
 				Example:     `[5, 4, 3, 2, 5, 7, 8] | nuplot line`,
 				// Result:      &nu.Value{Value: []nu.Value{{Value: 10}, {Value: "foo"}}},
 			},
+			{
+				Description: `Plot two series from a record of lists.`,
+				Example:     `{a: [1 2 3], b: [4 5 6]} | nuplot line`,
+			},
+			{
+				Description: `Plot benchmark means with their standard deviation as shaded band.`,
+				Example:     `[[run mean stddev]; [1 10.2 0.8] [2 11.5 1.1] [3 9.8 0.5]] | nuplot line --xaxis run --error stddev`,
+			},
+			{
+				Description: `Plot a series with explicit lower and upper bounds as error bars.`,
+				Example:     `[[run mean min max]; [1 10.2 9.1 11.0] [2 11.5 10.0 12.8] [3 9.8 9.2 10.1]] | nuplot line --xaxis run --lower min --upper max --error-style bars`,
+			},
+			{
+				Description: `Plot one line per host from a table in long format.`,
+				Example:     `[[time host cpu]; [1 a 20] [1 b 35] [2 a 25] [3 a 22] [3 b 40]] | nuplot line --xaxis time --group-by host --y cpu`,
+			},
+			{
+				Description: `Plot a value of nested records against a nested timestamp.`,
+				Example:     `[[meta stats]; [{ts: 1} {cpu: 20}] [{ts: 2} {cpu: 25}] [{ts: 3} {cpu: 22}]] | nuplot line --xaxis meta.ts --y stats.cpu`,
+			},
+			{
+				Description: `Plot two series with missing values and interpolate the gaps.`,
+				Example:     `[[nr a b]; [1 1 5] [2 null 4] [3 3 null] [4 4 2]] | nuplot line --xaxis nr --missing interpolate`,
+			},
+			{
+				Description: `Plot the rate per second of a counter.`,
+				Example:     `[[time requests]; ["2024-06-01 08:00:00" 100] ["2024-06-01 08:01:00" 160] ["2024-06-01 08:03:00" 400]] | nuplot line --xaxis time --transform rate`,
+			},
+			{
+				Description: `Plot the depth of a queue as steps with the rolling maximum over 3 values.`,
+				Example:     `[[time depth]; [1 4] [2 4] [3 7] [4 2] [5 2] [6 5]] | nuplot line --xaxis time --style step-end --rolling 3 --rolling-fn max`,
+			},
+			{
+				Description: `Plot the memory usage with an exponential trend.`,
+				Example:     `[[day mem]; [1 100] [2 130] [3 172] [4 220] [5 290]] | nuplot line --xaxis day --trend exp`,
+			},
+			{
+				Description: `Return the slope of a linear trend instead of plotting it.`,
+				Example:     `[[day mem]; [1 100] [2 130] [3 172] [4 220] [5 290]] | nuplot line --xaxis day --trend-only | get mem.coefficients.1`,
+			},
+			{
+				Description: `Forecast the disk usage of the next 30 days with weekly seasons.`,
+				Example:     `open disk-usage.csv | nuplot line --xaxis date --y used --forecast 30 --season 7`,
+			},
+			{
+				Description: `Plot benchmark durations with time units on the axis.`,
+				Example:     `1..10 | each {|n| {n: $n, time: (timeit { 1..($n * 10000) | math sum })} } | nuplot line --xaxis n`,
+			},
+			{
+				Description: `Plot irregularly spaced measurements on a time axis.`,
+				Example:     `[[time temp]; ["2024-06-01 08:00:00" 12] ["2024-06-01 09:30:00" 15] ["2024-06-01 14:00:00" 21]] | nuplot line --xaxis time --x-format "%H:%M" --timezone Europe/Berlin`,
+			},
+			{
+				Description: `Plot the run time of an algorithm on logarithmic axes.`,
+				Example:     `[[n time]; [10 0.1] [100 1.2] [1000 15] [10000 170]] | nuplot line --xaxis n --xlog --ylog --xname "input size" --yname "ms"`,
+			},
+			{
+				Description: `Plot a long time series with at most 2000 points.`,
+				Example:     `1..1000000 | each {|i| $i mod 1000 } | nuplot line --max-points 2000`,
+			},
+			{
+				Description: `Embed a large series as binary data to keep the chart file small.`,
+				Example:     `1..1000000 | each {|i| $i mod 1000 } | nuplot line --compact`,
+			},
+			{
+				Description: `Plot a CSV file without converting it to a table first.`,
+				Example:     `open --raw measurements.csv | nuplot line --xaxis time`,
+			},
 		},
 		OnRun: nuplotLineHandler,
 	}
@@ -61,107 +173,450 @@ func NuplotLine() *nu.Command {
 
 func nuplotLineHandler(ctx context.Context, call *nu.ExecCommand) error {
 	checkVerboseFlag(call)
-	return handleCommandInput(call, plotLine)
+
+	if getBoolFlag(call, flags.TrendOnly.Long) {
+		return handleTableInput(call, func(input any, call *nu.ExecCommand) error {
+			return returnLineTrends(ctx, input, call)
+		})
+	}
+	return handleTableInput(call, withFacets(plotLine, buildLine))
 }
 
-func plotLine(input any, call *nu.ExecCommand) error {
-	series := make(LineDataSeries)
-
-	xAxisName := getCellPathFlag(call, "xaxis", XAxisSeries)
-	slog.Debug("plotLine", "xAxisName", xAxisName)
-
-	switch inputValue := input.(type) {
-	case []nu.Value:
-		for itemIndex, item := range inputValue {
-			switch itemValue := item.Value.(type) {
-			case int64:
-				items := getSeries(series, DefaultSeries)
-				series[DefaultSeries] = append(items, opts.LineData{Value: itemValue})
-			case float64:
-				items := getSeries(series, DefaultSeries)
-				series[DefaultSeries] = append(items, opts.LineData{Value: itemValue})
-			case nu.Record:
-				// Try to set xAxisName to one of the columns in the record.
-				if itemIndex == 0 {
-					xAxisName = autoSetXaxis(itemValue, xAxisName)
-				}
-
-				for k, v := range itemValue {
-					if k == xAxisName {
-						continue
-					}
-
-					_, ok1 := v.Value.(int64)
-					_, ok2 := v.Value.(float64)
-					if ok1 || ok2 {
-						items := getSeries(series, k)
-						series[k] = append(items, opts.LineData{Value: v.Value})
-					}
-				}
-
-				// If a xaxis is defined, fill the series with the values.
-				if xAxisName != XAxisSeries {
-					if v, ok := itemValue[xAxisName]; ok {
-						items := getSeries(series, xAxisName)
-						series[xAxisName] = append(items, opts.LineData{Value: matchXValue(v)})
-					} else {
-						slog.Warn("Specified x-axis is not continuous. Reseting x-axis to default value.")
-						// If the column specified in --xaxis does not exist, we
-						// set the `xAxisName` variable to XAxisSeries, so that a
-						// simple int range is generated as x axis.
-						xAxisName = XAxisSeries
-					}
-				}
-			default:
-				return fmt.Errorf("plotLine: unsupported input value type: %T", inputValue)
-			}
+// List of all available line styles
+var LineStyles = []string{"smooth", "straight", "step-start", "step-middle", "step-end"}
+
+// Reads the --style, --symbols and --line-width flags and returns the options
+// of the line series.
+func getLineStyle(call *nu.ExecCommand) (opts.LineChart, opts.LineStyle, error) {
+	var lineChart opts.LineChart
+	var lineStyle opts.LineStyle
+
+	style := getStringFlag(call, flags.Style.Long, flags.Style.Default.Value.(string))
+	switch style {
+	case "smooth":
+		lineChart.Smooth = opts.Bool(true)
+	case "straight":
+	case "step-start", "step-middle", "step-end":
+		lineChart.Step = strings.TrimPrefix(style, "step-")
+	default:
+		return lineChart, lineStyle, fmt.Errorf("invalid --style %q, expected one of: %v", style, LineStyles)
+	}
+
+	// Without --symbols echarts decides, if the markers are shown.
+	if value, _ := call.FlagValue(flags.Symbols.Long); value.Value != nil {
+		lineChart.ShowSymbol = opts.Bool(value.Value.(bool))
+	}
+
+	if width, ok := getFloatFlag(call, flags.LineWidth.Long); ok {
+		if width <= 0 {
+			return lineChart, lineStyle, flagError(call, flags.LineWidth, fmt.Errorf("the line width has to be positive, got %v", width))
 		}
+		lineStyle.Width = float32(width)
+	}
+
+	return lineChart, lineStyle, nil
+}
+
+// Adds the column with the given name as dashed line without markers.
+func addDashedLine(line *charts.Line, name string, positions []float64, table *numericTable, lineChart opts.LineChart, lineStyle opts.LineStyle) {
+	lineChart.ShowSymbol = opts.Bool(false)
+	lineStyle.Type = "dashed"
+
+	seriesOpts := []charts.SeriesOpts{
+		charts.WithLineChartOpts(lineChart),
+		charts.WithLineStyleOpts(lineStyle),
+	}
+	line.AddSeries(name, float64ToLineDataAt(positions, table.Columns[name]),
+		append(seriesOpts, seriesKindOpts(table.Kinds[name])...)...)
+}
+
+// Column names of a table that hold the uncertainty of the plotted series.
+// Either Error holds a symmetric error, or Lower and Upper hold the bounds.
+type lineErrorColumns struct {
+	Error string
+	Lower string
+	Upper string
+	Style string
+}
+
+// Reads the error column flags from the call. The returned bool is true, if
+// error columns are given on the command line.
+func getLineErrorColumns(call *nu.ExecCommand) (lineErrorColumns, bool, error) {
+	columns := lineErrorColumns{
+		Error: getCellPathFlag(call, flags.Error.Long, ""),
+		Lower: getCellPathFlag(call, flags.Lower.Long, ""),
+		Upper: getCellPathFlag(call, flags.Upper.Long, ""),
+		Style: getStringFlag(call, flags.ErrorStyle.Long, flags.ErrorStyle.Default.Value.(string)),
+	}
+
+	if columns.Style != "band" && columns.Style != "bars" {
+		return columns, false, fmt.Errorf("invalid --error-style %q, expected one of: band, bars", columns.Style)
+	}
+
+	switch {
+	case columns.Error != "" && (columns.Lower != "" || columns.Upper != ""):
+		return columns, false, fmt.Errorf("--error can not be combined with --lower and --upper")
+	case (columns.Lower != "") != (columns.Upper != ""):
+		return columns, false, fmt.Errorf("the flags --lower and --upper have to be given together")
 	default:
-		return fmt.Errorf("plotLine: unsupported input value type: %T", inputValue)
+		return columns, columns.Error != "" || columns.Lower != "", nil
+	}
+}
+
+// Returns the lower and upper bounds of the series from the error columns of
+// the table. The error columns are removed from the table.
+func extractLineBounds(table *numericTable, ySeries string, columns lineErrorColumns) (lower, upper []float64, res error) {
+	values := table.Columns[ySeries]
+
+	pull := func(name string) ([]float64, error) {
+		data, ok := table.Columns[name]
+		if !ok {
+			return nil, fmt.Errorf("column %q not found in input", name)
+		}
+		delete(table.Columns, name)
+
+		return data, nil
+	}
+
+	if columns.Error != "" {
+		deltas, err := pull(columns.Error)
+		if err != nil {
+			res = err
+			return
+		}
+
+		lower = make([]float64, len(values))
+		upper = make([]float64, len(values))
+		for i := range values {
+			lower[i] = values[i] - deltas[i]
+			upper[i] = values[i] + deltas[i]
+		}
+		return
+	}
+
+	if lower, res = pull(columns.Lower); res != nil {
+		return
+	}
+	upper, res = pull(columns.Upper)
+
+	return
+}
+
+// Prefix of the ids of the helper series, that draw the shaded bands. They are
+// removed from the legend and the tooltip, see [hideBandSeries].
+const bandSeriesID = "band:"
+
+// Javascript that disables the tooltip of the series with the given ids.
+// go-echarts has no series option for it.
+const hideTooltipScript = `%%MY_ECHARTS%%.setOption({series: %s});`
+
+// Draws the bounds as shaded band around the series. The band is built from
+// two stacked lines: an invisible one at the lower bound and a filled one with
+// the distance between the bounds on top of it.
+func addLineErrorBand(line *charts.Line, name string, positions []float64, lower, upper []float64, style opts.LineChart) {
+	width := make([]float64, len(lower))
+	for i := range lower {
+		width[i] = upper[i] - lower[i]
+	}
+
+	band := charts.NewLine()
+	bandOpts := []charts.SeriesOpts{
+		charts.WithLineChartOpts(opts.LineChart{
+			Stack:      name + " band",
+			ShowSymbol: opts.Bool(false),
+			Smooth:     style.Smooth,
+			Step:       style.Step,
+		}),
+		charts.WithLineStyleOpts(opts.LineStyle{Opacity: opts.Float(0)}),
+	}
+
+	band.AddSeries(name+" lower", float64ToLineDataAt(positions, lower),
+		append(bandOpts, withSeriesID(bandSeriesID+name+" lower"))...,
+	)
+	band.AddSeries(name+" band", float64ToLineDataAt(positions, width),
+		append(bandOpts, withSeriesID(bandSeriesID+name+" band"),
+			charts.WithAreaStyleOpts(opts.AreaStyle{Opacity: opts.Float(0.3)}))...,
+	)
+
+	line.Overlap(band)
+}
+
+// Sets the id of a series.
+func withSeriesID(id string) charts.SeriesOpts {
+	return func(s *charts.SingleSeries) {
+		s.Id = id
+	}
+}
+
+// Removes the helper series of the shaded bands from the legend and disables
+// their tooltip, so that only the series they belong to are listed.
+func hideBandSeries(line *charts.Line) error {
+	names := make([]string, 0, len(line.MultiSeries))
+	hidden := make([]map[string]any, 0)
+	for _, s := range line.MultiSeries {
+		if strings.HasPrefix(s.Id, bandSeriesID) {
+			hidden = append(hidden, map[string]any{"id": s.Id, "tooltip": map[string]any{"show": false}})
+		} else {
+			names = append(names, s.Name)
+		}
+	}
+	if len(hidden) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(hidden)
+	if err != nil {
+		return fmt.Errorf("hideBandSeries: %w", err)
+	}
+
+	line.SetGlobalOptions(charts.WithLegendOpts(opts.Legend{Data: names}))
+	line.AddJSFuncs(fmt.Sprintf(hideTooltipScript, data))
+	return nil
+}
+
+// Javascript function that draws a vertical error bar with caps for each data
+// item of a custom series. The data items hold [x position, lower, upper].
+// The caps have a minimum width, because a time axis has no category width.
+const errorBarRenderItem = `function (params, api) {
+	var low = api.coord([api.value(0), api.value(1)]);
+	var high = api.coord([api.value(0), api.value(2)]);
+	var cap = Math.max(api.size([1, 0])[0] * 0.15, 4);
+	var style = api.style({ stroke: api.visual('color'), fill: null });
+	return {
+		type: 'group',
+		children: [
+			{ type: 'line', shape: { x1: high[0] - cap, y1: high[1], x2: high[0] + cap, y2: high[1] }, style: style },
+			{ type: 'line', shape: { x1: high[0], y1: high[1], x2: low[0], y2: low[1] }, style: style },
+			{ type: 'line', shape: { x1: low[0] - cap, y1: low[1], x2: low[0] + cap, y2: low[1] }, style: style }
+		]
+	};
+}`
+
+// Draws the bounds as error bars on each data point of the series.
+func addLineErrorBars(line *charts.Line, name string, positions []float64, lower, upper []float64) {
+	data := make([]opts.CustomData, 0, len(lower))
+	for i := range lower {
+		// Rows with missing bounds get no error bar.
+		if math.IsNaN(lower[i]) || math.IsNaN(upper[i]) {
+			continue
+		}
+		x := float64(i)
+		if positions != nil {
+			x = positions[i]
+		}
+		data = append(data, opts.CustomData{Value: []float64{x, lower[i], upper[i]}})
+	}
+
+	bars := charts.NewCustom()
+	bars.AddSeries(name+" error", data,
+		charts.WithCustomChartOpts(opts.CustomChart{
+			RenderItem: opts.FuncOpts(errorBarRenderItem),
+		}),
+		charts.WithEncodeOpts(opts.Encode{X: 0, Y: []int{1, 2}}),
+	)
+
+	line.Overlap(bars)
+}
+
+func buildLine(input any, call *nu.ExecCommand) (*charts.Line, valueRange, error) {
+	table, ok := input.(*numericTable)
+	if !ok {
+		return nil, valueRange{}, fmt.Errorf("plotLine: unsupported input value type: %T", input)
+	}
+	slog.Debug("plotLine", "xAxisName", table.XAxisName)
+
+	errorColumns, errorsGiven, err := getLineErrorColumns(call)
+	if err != nil {
+		return nil, valueRange{}, err
+	}
+	slog.Debug("plotLine", "errorColumns", errorColumns, "errorsGiven", errorsGiven)
+
+	seriesNames, err := table.selectColumns(call,
+		errorColumns.Error, errorColumns.Lower, errorColumns.Upper)
+	if err != nil {
+		return nil, valueRange{}, fmt.Errorf("plotLine: %w", err)
+	}
+
+	// The error columns are read like all other columns and then paired with
+	// the only remaining series.
+	var lower, upper []float64
+	ySeries := ""
+	if errorsGiven {
+		switch len(seriesNames) {
+		case 0:
+			return nil, valueRange{}, fmt.Errorf("plotLine: no series found to plot errors and bounds for")
+		case 1:
+			ySeries = seriesNames[0]
+		default:
+			return nil, valueRange{}, fmt.Errorf("plotLine: errors and bounds can only be plotted for a single series, found %q, use --y to select one", seriesNames)
+		}
+
+		// Rows with missing errors are handled like rows with missing values.
+		missingColumns := []string{ySeries}
+		for _, name := range []string{errorColumns.Error, errorColumns.Lower, errorColumns.Upper} {
+			if _, ok := table.Columns[name]; ok {
+				missingColumns = append(missingColumns, name)
+			}
+		}
+		if err := table.applyMissingPolicy(call, missingColumns); err != nil {
+			return nil, valueRange{}, fmt.Errorf("plotLine: %w", err)
+		}
+	} else if err := table.applyMissingPolicy(call, seriesNames); err != nil {
+		return nil, valueRange{}, fmt.Errorf("plotLine: %w", err)
+	}
+
+	if errorsGiven && getStringFlag(call, flags.Transform.Long, "") != "" {
+		return nil, valueRange{}, flagError(call, flags.Transform, fmt.Errorf("transforms can not be combined with errors and bounds"))
+	}
+	if err := table.applyTransform(call, seriesNames); err != nil {
+		return nil, valueRange{}, fmt.Errorf("plotLine: %w", err)
+	}
+
+	lineChart, lineStyle, err := getLineStyle(call)
+	if err != nil {
+		return nil, valueRange{}, err
+	}
+
+	// The rolling windows are stored as extra columns, so that they are
+	// downsampled like the series they belong to.
+	rollingColumns, err := table.addRollingColumns(call, seriesNames)
+	if err != nil {
+		return nil, valueRange{}, err
+	}
+
+	// The forecasts append rows to the table, so that the trends are
+	// continued up to the end of the forecasts.
+	forecasts, err := table.addForecastColumns(call, seriesNames)
+	if err != nil {
+		return nil, valueRange{}, err
+	}
+
+	trendColumns, err := table.addTrendColumns(call, seriesNames)
+	if err != nil {
+		return nil, valueRange{}, err
+	}
+
+	// The rows of the forecasts have no values in the series, so they are
+	// downsampled along with the forecasts.
+	downsampleNames := slices.Clone(seriesNames)
+	for _, f := range forecasts {
+		downsampleNames = append(downsampleNames, f.Forecast)
+	}
+	downsampleOpts, err := table.downsample(call, downsampleNames, false)
+	if err != nil {
+		return nil, valueRange{}, err
+	}
+
+	if errorsGiven {
+		if lower, upper, err = extractLineBounds(table, ySeries, errorColumns); err != nil {
+			return nil, valueRange{}, fmt.Errorf("plotLine: %w", err)
+		}
 	}
 
 	// create a new line instance
 	line := charts.NewLine()
 
 	line.SetGlobalOptions(buildGlobalChartOptions(call)...)
+	if downsampleOpts != nil {
+		line.SetGlobalOptions(downsampleOpts)
+	}
 
 	// Reverse X/Y (only on bar charts)
 	// line.XYReversal()
 
+	axisOpts, err := buildAxisOptions(call)
+	if err != nil {
+		return nil, valueRange{}, err
+	}
+	line.SetGlobalOptions(axisOpts...)
+
+	// Dates on the x-axis are plotted on a time axis with proportional
+	// spacing and numbers on a logarithmic axis, if requested. All other x
+	// values are plotted as categories.
+	positions, xAxisOpts, err := table.xAxisOptions(call)
+	if err != nil {
+		return nil, valueRange{}, err
+	}
+	var categories []any
+	if positions != nil {
+		line.SetGlobalOptions(xAxisOpts...)
+	} else {
+		categories = table.xValues()
+		line = line.SetXAxis(categories)
+	}
+
+	yValues := [][]float64{lower}
+	for _, sName := range seriesNames {
+		yValues = append(yValues, table.Columns[sName])
+	}
+	if err := checkLogAxisValues(call, flags.YLog, yValues...); err != nil {
+		return nil, valueRange{}, err
+	}
+
 	// Put data into instance
-	itemCount := 0
-	for sName, sValues := range series {
-		if sName == xAxisName {
-			continue
+	plotted := newValueRange()
+	for _, sName := range seriesNames {
+		slog.Debug("plotLine: Adding items to series", "series", sName, "items", table.Rows)
+		plotted.add(table.Columns[sName]...)
+		seriesOpts := []charts.SeriesOpts{
+			charts.WithLineChartOpts(lineChart),
+			charts.WithLineStyleOpts(lineStyle),
 		}
+		line = line.AddSeries(sName, float64ToLineDataAt(positions, table.Columns[sName]),
+			append(seriesOpts, seriesKindOpts(table.Kinds[sName])...)...)
 
-		itemCount = len(sValues)
-		slog.Debug("plotLine: Adding items to series", "series", sName, "items", itemCount)
-		line = line.AddSeries(sName, sValues)
+		// Rolling windows and trends are drawn as dashed lines without
+		// markers. Trends are not smoothed, so that curves are not bent.
+		if rName, ok := rollingColumns[sName]; ok {
+			plotted.add(table.Columns[rName]...)
+			addDashedLine(line, rName, positions, table, lineChart, lineStyle)
+		}
+		if tName, ok := trendColumns[sName]; ok {
+			plotted.add(table.Columns[tName]...)
+			addDashedLine(line, tName, positions, table, opts.LineChart{}, lineStyle)
+		}
+		if f, ok := forecasts[sName]; ok {
+			plotted.add(table.Columns[f.Lower]...)
+			plotted.add(table.Columns[f.Upper]...)
+			addDashedLine(line, f.Forecast, positions, table, lineChart, lineStyle)
+			addLineErrorBand(line, f.Forecast, positions, table.Columns[f.Lower], table.Columns[f.Upper], lineChart)
+		}
 	}
+	line.SetGlobalOptions(withValueAxisKind(commonKind(table.Kinds, seriesNames), false))
 
-	if xAxisName != XAxisSeries {
-		line = line.SetXAxis(series[xAxisName])
-	} else {
-		xRange := make([]int, itemCount)
-		for i := range itemCount {
-			xRange[i] = i
+	// The error series are added with their own stack and style settings.
+	if errorsGiven {
+		plotted.add(lower...)
+		plotted.add(upper...)
+		if errorColumns.Style == "bars" {
+			addLineErrorBars(line, ySeries, positions, lower, upper)
+		} else {
+			addLineErrorBand(line, ySeries, positions, lower, upper, lineChart)
 		}
+	}
 
-		line = line.SetXAxis(xRange)
+	if err := hideBandSeries(line); err != nil {
+		return nil, valueRange{}, fmt.Errorf("plotLine: %w", err)
 	}
 
-	line.SetSeriesOptions(
-		charts.WithLineChartOpts(opts.LineChart{
-			Smooth: opts.Bool(true),
-		}),
-		// For bar charts
-		// charts.WithBarChartOpts(opts.BarChart{
-		// 	Stack: "stackA",
-		// }),
-	)
+	if getBoolFlag(call, flags.Compact.Long) {
+		if err := compactChart(&line.BaseConfiguration, categories, "xAxis"); err != nil {
+			return nil, valueRange{}, fmt.Errorf("plotLine: %w", err)
+		}
+		// The categories are set by the decoder script.
+		line = line.SetXAxis(nil)
+	}
 
 	setPageTitle(call, &line.BaseConfiguration)
 
+	return line, plotted, nil
+}
+
+func plotLine(input any, call *nu.ExecCommand) error {
+	line, _, err := buildLine(input, call)
+	if err != nil {
+		return err
+	}
+
 	return renderChart(func(f *os.File) error { return line.Render(f) })
 }
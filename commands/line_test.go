@@ -0,0 +1,135 @@
+package commands
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+
+	"github.com/ainvaltin/nu-plugin"
+)
+
+func TestAddLineErrorBand(t *testing.T) {
+	line := charts.NewLine()
+	addLineErrorBand(line, "a", nil, []float64{1, 2, 3}, []float64{2, 4, 3}, opts.LineChart{})
+
+	if len(line.MultiSeries) != 2 {
+		t.Fatalf("got %d series, want the lower bound and the band", len(line.MultiSeries))
+	}
+	lower, band := line.MultiSeries[0], line.MultiSeries[1]
+	if lower.Id != bandSeriesID+"a lower" || band.Id != bandSeriesID+"a band" {
+		t.Errorf("got ids %q and %q, want both with prefix %q", lower.Id, band.Id, bandSeriesID)
+	}
+	if lower.Stack == "" || lower.Stack != band.Stack {
+		t.Errorf("got stacks %q and %q, want the band stacked onto the lower bound", lower.Stack, band.Stack)
+	}
+
+	// The band holds the distance between the bounds.
+	widths := make([]any, 0, 3)
+	for _, item := range band.Data.([]opts.LineData) {
+		widths = append(widths, item.Value)
+	}
+	if want := []any{1.0, 2.0, 0.0}; !slices.Equal(widths, want) {
+		t.Errorf("got widths %v, want %v", widths, want)
+	}
+}
+
+func TestHideBandSeries(t *testing.T) {
+	tests := []struct {
+		name       string
+		bands      []string
+		wantLegend []string
+	}{
+		{
+			name: "no bands",
+		},
+		{
+			name:       "one band",
+			bands:      []string{"a"},
+			wantLegend: []string{"a", "b"},
+		},
+		{
+			name:       "two bands",
+			bands:      []string{"a", "b"},
+			wantLegend: []string{"a", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := charts.NewLine()
+			for _, name := range []string{"a", "b"} {
+				line.AddSeries(name, float64ToLineData([]float64{1, 2, 3}))
+				if slices.Contains(tt.bands, name) {
+					addLineErrorBand(line, name, nil, []float64{0, 1, 2}, []float64{2, 3, 4}, opts.LineChart{})
+				}
+			}
+
+			if err := hideBandSeries(line); err != nil {
+				t.Fatal(err)
+			}
+
+			if legend, _ := line.Legend.Data.([]string); !slices.Equal(legend, tt.wantLegend) {
+				t.Errorf("got legend %v, want %v", line.Legend.Data, tt.wantLegend)
+			}
+
+			script := ""
+			for _, fn := range line.JSFunctions.Fns {
+				if strings.Contains(string(fn), "tooltip") {
+					script = string(fn)
+				}
+			}
+			if len(tt.bands) == 0 && script != "" {
+				t.Errorf("got script %q, want none", script)
+			}
+			for _, name := range tt.bands {
+				for _, id := range []string{bandSeriesID + name + " lower", bandSeriesID + name + " band"} {
+					if !strings.Contains(script, `{"id":"`+id+`","tooltip":{"show":false}}`) {
+						t.Errorf("got script %q, want the tooltip of %q disabled", script, id)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestGetLineStyleDefaults(t *testing.T) {
+	lineChart, lineStyle, err := getLineStyle(&nu.ExecCommand{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lineChart.Smooth == nil || !*lineChart.Smooth || lineChart.Step != nil {
+		t.Errorf("got smooth %v and step %v, want smooth lines", lineChart.Smooth, lineChart.Step)
+	}
+	if lineChart.ShowSymbol != nil || lineStyle.Width != 0 {
+		t.Errorf("got symbols %v and width %v, want the defaults of echarts", lineChart.ShowSymbol, lineStyle.Width)
+	}
+}
+
+func TestAddDashedLine(t *testing.T) {
+	table, err := readNumericTable(nuValues(nu.Filesize(1024), nu.Filesize(2048)), XAxisSeries, time.UTC, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The dashed line keeps the step and the width of the other lines.
+	line := charts.NewLine()
+	addDashedLine(line, DefaultSeries, nil, table, opts.LineChart{Step: "end"}, opts.LineStyle{Width: 3})
+
+	if len(line.MultiSeries) != 1 {
+		t.Fatalf("got %d series, want 1", len(line.MultiSeries))
+	}
+	s := line.MultiSeries[0]
+	if s.LineStyle == nil || s.LineStyle.Type != "dashed" || s.LineStyle.Width != 3 {
+		t.Errorf("got line style %+v, want a dashed line of width 3", s.LineStyle)
+	}
+	if s.Step != "end" || s.ShowSymbol == nil || *s.ShowSymbol {
+		t.Errorf("got step %v and symbols %v, want step end without symbols", s.Step, s.ShowSymbol)
+	}
+	if s.SeriesTooltip == nil {
+		t.Error("got no tooltip formatter, want filesize labels")
+	}
+}
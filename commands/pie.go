@@ -32,6 +32,12 @@ func NuplotPie() *nu.Command {
 			SearchTerms: []string{"plot", "graph", "pie"},
 			// OptionalPositional: nu.PositionalArgs{},
 			Named: []nu.Flag{
+				flags.XAxis,
+				flags.Label,
+				flags.Value,
+				flags.Y,
+				flags.Exclude,
+				flags.Agg,
 				flags.Title,
 				flags.SubTitle,
 				flags.Width,
@@ -41,6 +47,9 @@ func NuplotPie() *nu.Command {
 			},
 			InputOutputTypes: []nu.InOutTypes{
 				{In: types.Record(types.RecordDef{}), Out: types.Nothing()},
+				{In: types.Table(types.RecordDef{}), Out: types.Nothing()},
+				{In: types.String(), Out: types.Nothing()},
+				{In: types.Binary(), Out: types.Nothing()},
 				{In: types.List(types.Number()), Out: types.Nothing()},
 			},
 			AllowMissingExamples: true,
@@ -50,6 +59,14 @@ func NuplotPie() *nu.Command {
 				Description: `Plot a pie graph of an array of numbers.`,
 				Example:     `{'apples': 7 'oranges': 5 'bananas': 3} | nuplot pie --title "Fruits"`,
 			},
+			{
+				Description: `Plot the total amount of sales per product.`,
+				Example:     `[[product amount]; [apples 3] [oranges 5] [apples 4] [bananas 3]] | nuplot pie --xaxis product --agg sum`,
+			},
+			{
+				Description: `Plot the size of the files in the current directory.`,
+				Example:     `ls | nuplot pie --label name --value size`,
+			},
 		},
 		OnRun: nuplotPieHandler,
 	}
@@ -64,12 +81,23 @@ func plotPie(input any, call *nu.ExecCommand) error {
 	series := make(PieDataSeries)
 
 	seriesName := getStringFlag(call, flags.Title.Long, "Items")
-	slog.Debug("plotPie", "seriesName", seriesName)
+	xAxisName := getCellPathFlag(call, flags.XAxis.Long, XAxisSeries)
+	// --label names the slices like --xaxis, but reads better for pie charts.
+	if labelName := getCellPathFlag(call, flags.Label.Long, ""); labelName != "" {
+		xAxisName = labelName
+	}
+	valueName := getCellPathFlag(call, flags.Value.Long, "")
+	slog.Debug("plotPie", "seriesName", seriesName, "xAxisName", xAxisName, "valueName", valueName)
 	valueCount := 0
 
+	input, err := aggregateRows(input, call)
+	if err != nil {
+		return err
+	}
+
 	switch inputValue := input.(type) {
 	case []nu.Value:
-		for _, item := range inputValue {
+		for itemIndex, item := range inputValue {
 			switch itemValue := item.Value.(type) {
 			case int64:
 				items := getSeries(series, seriesName)
@@ -91,17 +119,49 @@ func plotPie(input any, call *nu.ExecCommand) error {
 						Value: itemValue,
 					},
 				)
+			case nu.Record:
+				// Each row is a slice, named by the x-axis column.
+				if itemIndex == 0 {
+					xAxisName = autoSetXaxis(itemValue, xAxisName)
+				}
+
+				valueCount += 1
+				name := fmt.Sprintf("Value %d", valueCount)
+				if v, ok := itemValue[xAxisName]; ok {
+					name = fmt.Sprint(v.Value)
+				}
+
+				columns := []string{valueName}
+				if valueName == "" {
+					if columns, err = selectSeries(itemValue, call, xAxisName); err != nil {
+						return fmt.Errorf("plotPie: row %d: %w", itemIndex, err)
+					}
+				} else if _, ok := itemValue[valueName]; !ok {
+					return fmt.Errorf("plotPie: row %d: column %q given in --value was not found", itemIndex, valueName)
+				}
+
+				for _, k := range columns {
+					v := itemValue[k]
+					if f, err := ValueToFloat64(v); err == nil && k != xAxisName {
+						items := getSeries(series, seriesName)
+						series[seriesName] = append(items, opts.PieData{Name: name, Value: f})
+					}
+				}
 			default:
 				return fmt.Errorf("plotPie: unsupported input value type: %T", inputValue)
 			}
 		}
 	case nu.Record:
-		for k, v := range inputValue {
-			_, ok1 := v.Value.(int64)
-			_, ok2 := v.Value.(float64)
-			if ok1 || ok2 {
+		columns, err := selectSeries(inputValue, call)
+		if err != nil {
+			return fmt.Errorf("plotPie: %w", err)
+		}
+
+		for _, k := range columns {
+			v := inputValue[k]
+			if f, err := ValueToFloat64(v); err == nil {
 				items := getSeries(series, seriesName)
-				series[seriesName] = append(items, opts.PieData{Name: k, Value: v.Value})
+				series[seriesName] = append(items, opts.PieData{Name: k, Value: f})
 			}
 		}
 	default:
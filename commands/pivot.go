@@ -0,0 +1,136 @@
+package commands
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/ainvaltin/nu-plugin"
+
+	"github.com/gtnebel/nu_plugin_nuplot/commands/flags"
+)
+
+// Converts a table in long format into a table in wide format, if the
+// --group-by flag is given. Otherwise the input and the given order of its
+// columns are returned unchanged.
+//
+// Each distinct value of the group column becomes its own column holding the
+// values of the --y column. The rows are aligned on the values of the x-axis
+// column. Combinations of x value and group that do not exist in the input are
+// filled with null values, so that they show up as gaps in the chart. The
+// columns of the new table are returned in the order in which their group
+// values first appear.
+func pivotByGroup(input any, order []string, call *nu.ExecCommand) (any, []string, error) {
+	groupName := getCellPathFlag(call, flags.GroupBy.Long, "")
+	if groupName == "" {
+		return input, order, nil
+	}
+
+	table, ok := input.([]nu.Value)
+	if !ok || len(table) == 0 {
+		return input, order, nil
+	}
+
+	res, columns, err := pivotRows(table, getCellPathFlag(call, flags.XAxis.Long, XAxisSeries), groupName, getStringListFlag(call, flags.Y.Long))
+	if err != nil {
+		return nil, nil, fmt.Errorf("pivotByGroup: %w", err)
+	}
+	return res, columns, nil
+}
+
+// Pivots the rows of a table in long format, see [pivotByGroup]. If no value
+// columns are given, the table needs exactly one numeric column besides the
+// x-axis and group columns. The names of the x-axis column and the series are
+// returned along with the rows.
+func pivotRows(table []nu.Value, xAxisName, groupName string, yColumns []string) ([]nu.Value, []string, error) {
+	first, ok := table[0].Value.(nu.Record)
+	if !ok {
+		return nil, nil, fmt.Errorf("--group-by needs a table as input, got %T", table[0].Value)
+	}
+
+	xAxisName = autoSetXaxis(first, xAxisName)
+	if xAxisName == XAxisSeries {
+		return nil, nil, fmt.Errorf("--group-by needs an x-axis column, use --xaxis")
+	}
+
+	if len(yColumns) == 0 {
+		for k, v := range first {
+			if _, err := ValueToFloat64(v); err == nil && k != xAxisName && k != groupName {
+				yColumns = append(yColumns, k)
+			}
+		}
+		if len(yColumns) != 1 {
+			return nil, nil, fmt.Errorf("found %d value columns, use --y to select one", len(yColumns))
+		}
+	}
+	slog.Debug("pivotRows", "group", groupName, "xAxisName", xAxisName, "y", yColumns)
+
+	// The names of the series that are built from a group value and a value
+	// column. The group value alone is used, if there is only one column.
+	seriesName := func(group string, column string) string {
+		if len(yColumns) == 1 {
+			return group
+		}
+		return group + " " + column
+	}
+
+	// Rows and series names keep the order of their first appearance.
+	xValues := make([]nu.Value, 0)
+	xKeys := make([]string, 0)
+	cells := make(map[string]map[string][]nu.Value)
+	seriesNames := make([]string, 0)
+
+	for itemIndex, item := range table {
+		record, ok := item.Value.(nu.Record)
+		if !ok {
+			return nil, nil, fmt.Errorf("unsupported input value type %T in row %d", item.Value, itemIndex)
+		}
+
+		x, ok := record[xAxisName]
+		if !ok {
+			return nil, nil, fmt.Errorf("row %d has no column %q", itemIndex, xAxisName)
+		}
+		xKey := fmt.Sprint(x.Value)
+		if _, ok := cells[xKey]; !ok {
+			xValues = append(xValues, x)
+			xKeys = append(xKeys, xKey)
+			cells[xKey] = make(map[string][]nu.Value)
+		}
+
+		group := fmt.Sprint(record[groupName].Value)
+		for _, column := range yColumns {
+			v, ok := record[column]
+			if !ok {
+				continue
+			}
+
+			name := seriesName(group, column)
+			if !slices.Contains(seriesNames, name) {
+				seriesNames = append(seriesNames, name)
+			}
+			cells[xKey][name] = append(cells[xKey][name], v)
+		}
+	}
+
+	res := make([]nu.Value, len(xKeys))
+	for i, xKey := range xKeys {
+		row := nu.Record{xAxisName: xValues[i]}
+
+		for _, name := range seriesNames {
+			values := cells[xKey][name]
+			switch len(values) {
+			case 0:
+				row[name] = nu.Value{Value: nil}
+			case 1:
+				row[name] = values[0]
+			default:
+				slog.Warn("Found more than one value for the same x value and group. Using the last one.", "x", xKey, "series", name)
+				row[name] = values[len(values)-1]
+			}
+		}
+
+		res[i] = nu.Value{Value: row}
+	}
+
+	return res, append([]string{xAxisName}, seriesNames...), nil
+}
@@ -0,0 +1,146 @@
+package commands
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/ainvaltin/nu-plugin"
+)
+
+// Returns the plain values of the given columns for each row of a table.
+func rowValues(table []nu.Value, columns []string) [][]any {
+	res := make([][]any, len(table))
+	for i, row := range table {
+		record := row.Value.(nu.Record)
+		for _, column := range columns {
+			res[i] = append(res[i], record[column].Value)
+		}
+	}
+	return res
+}
+
+func TestPivotRows(t *testing.T) {
+	long := records(
+		map[string]any{"date": "mon", "host": "a", "cpu": int64(1), "mem": int64(10)},
+		map[string]any{"date": "mon", "host": "b", "cpu": int64(2), "mem": int64(20)},
+		map[string]any{"date": "tue", "host": "b", "cpu": int64(3), "mem": int64(30)},
+		map[string]any{"date": "wed", "host": "a", "cpu": int64(4), "mem": int64(40)},
+		map[string]any{"date": "wed", "host": "a", "cpu": int64(5), "mem": int64(50)},
+	)
+
+	tests := []struct {
+		name      string
+		table     []nu.Value
+		xAxisName string
+		yColumns  []string
+		columns   []string
+		rows      [][]any
+	}{
+		{
+			name:      "gaps are filled with nulls",
+			table:     long,
+			xAxisName: XAxisSeries,
+			yColumns:  []string{"cpu"},
+			columns:   []string{"date", "a", "b"},
+			rows: [][]any{
+				{"mon", int64(1), int64(2)},
+				{"tue", nil, int64(3)},
+				{"wed", int64(5), nil},
+			},
+		},
+		{
+			name:      "several value columns",
+			table:     long,
+			xAxisName: "date",
+			yColumns:  []string{"cpu", "mem"},
+			columns:   []string{"date", "a cpu", "a mem", "b cpu", "b mem"},
+			rows: [][]any{
+				{"mon", int64(1), int64(10), int64(2), int64(20)},
+				{"tue", nil, nil, int64(3), int64(30)},
+				{"wed", int64(5), int64(50), nil, nil},
+			},
+		},
+		{
+			name: "single numeric column",
+			table: records(
+				map[string]any{"nr": int64(1), "host": "b", "load": 0.5},
+				map[string]any{"nr": int64(1), "host": "a", "load": 0.25},
+			),
+			xAxisName: XAxisSeries,
+			columns:   []string{"nr", "b", "a"},
+			rows:      [][]any{{int64(1), 0.5, 0.25}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, columns, err := pivotRows(tt.table, tt.xAxisName, "host", tt.yColumns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(columns, tt.columns) {
+				t.Fatalf("got columns %v, want %v", columns, tt.columns)
+			}
+			if rows := rowValues(got, columns); !reflect.DeepEqual(rows, tt.rows) {
+				t.Errorf("got rows %v, want %v", rows, tt.rows)
+			}
+		})
+	}
+}
+
+func TestPivotRowsErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		table     []nu.Value
+		xAxisName string
+		yColumns  []string
+	}{
+		{
+			name:      "no table",
+			table:     nuValues(int64(1), int64(2)),
+			xAxisName: "x",
+		},
+		{
+			name:      "no x-axis column",
+			table:     records(map[string]any{"host": "a", "cpu": int64(1)}),
+			xAxisName: XAxisSeries,
+			yColumns:  []string{"cpu"},
+		},
+		{
+			name: "row without x value",
+			table: records(
+				map[string]any{"x": int64(1), "host": "a", "cpu": int64(1)},
+				map[string]any{"host": "b", "cpu": int64(2)},
+			),
+			xAxisName: "x",
+			yColumns:  []string{"cpu"},
+		},
+		{
+			name:      "ambiguous value columns",
+			table:     records(map[string]any{"x": int64(1), "host": "a", "cpu": int64(1), "mem": int64(2)}),
+			xAxisName: "x",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := pivotRows(tt.table, tt.xAxisName, "host", tt.yColumns); err == nil {
+				t.Error("got no error, want an error")
+			}
+		})
+	}
+}
+
+func TestPivotByGroupWithoutFlag(t *testing.T) {
+	input := records(map[string]any{"x": int64(1), "y": int64(2)})
+	order := []string{"x", "y"}
+
+	got, columns, err := pivotByGroup(input, order, &nu.ExecCommand{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, input) || !slices.Equal(columns, order) {
+		t.Errorf("got %v and %v, want the input unchanged", got, columns)
+	}
+}
@@ -0,0 +1,307 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ainvaltin/nu-plugin"
+)
+
+// Size of the buffer, that is used to detect the format of raw input.
+const sniffSize = 64 * 1024
+
+// Decodes raw byte stream input, e.g. from `open --raw`, into nushell values.
+// The format is detected from the start of the input: JSON arrays and
+// objects, newline delimited JSON and CSV or TSV tables are supported. Date
+// strings in CSV tables are interpreted in the given location. The names of
+// the columns are returned in the order of the input.
+func decodeRawInput(in io.Reader, loc *time.Location) (any, []string, error) {
+	reader := bufio.NewReaderSize(in, sniffSize)
+
+	head, err := reader.Peek(sniffSize)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, nil, fmt.Errorf("decodeRawInput: %w", err)
+	}
+
+	// Skip the UTF-8 byte order mark that is written by some spreadsheets.
+	if bom := []byte("\xef\xbb\xbf"); bytes.HasPrefix(head, bom) {
+		reader.Discard(len(bom))
+		head = head[len(bom):]
+	}
+
+	trimmed := bytes.TrimLeft(head, " \t\r\n")
+	if len(trimmed) == 0 {
+		return []nu.Value{}, nil, nil
+	}
+
+	switch trimmed[0] {
+	case '[', '{':
+		slog.Debug("decodeRawInput: input is JSON")
+		return decodeJSONInput(reader)
+	default:
+		delimiter := sniffDelimiter(trimmed)
+		slog.Debug("decodeRawInput: input is CSV", "delimiter", string(delimiter))
+		return decodeCSVInput(reader, delimiter, loc)
+	}
+}
+
+// Decodes raw input with [decodeRawInput] and evaluates the cell paths of the
+// flags against it, see [readCommandInput].
+func readRawInput(call *nu.ExecCommand, in io.Reader) (any, []string, error) {
+	loc, err := getTimezoneFlag(call)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	value, columns, err := decodeRawInput(in, loc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := resolveCellPaths(value, call); err != nil {
+		return nil, nil, err
+	}
+	return value, columns, nil
+}
+
+// Decodes a JSON document or a stream of newline delimited JSON values. A
+// single document is returned as it is, a record of lists is converted to a
+// table like structured input, see [recordOfListsToTable]. Multiple documents
+// are newline delimited JSON and always returned as a list. A single document
+// is never read as newline delimited JSON, as minified JSON, e.g. from
+// `to json --raw`, fits on one line as well.
+func decodeJSONInput(in io.Reader) (any, []string, error) {
+	decoder := newJSONDecoder(in)
+
+	values := make([]nu.Value, 0)
+	for decoder.dec.More() {
+		v, err := decoder.value(0)
+		if err != nil {
+			return nil, nil, fmt.Errorf("decodeJSONInput: value %d: %w", len(values), err)
+		}
+		values = append(values, v)
+	}
+
+	if len(values) == 1 {
+		return recordOfListsToTable(values[0].Value), decoder.columns, nil
+	}
+	return values, decoder.columns, nil
+}
+
+// Decodes JSON values token by token, so that the order of the keys of the
+// objects is known, which is lost when decoding into maps. The keys of the
+// objects at the top level and in top level lists are the columns of the
+// table.
+type jsonDecoder struct {
+	dec *json.Decoder
+	// The column names in the order in which they first appear
+	columns []string
+	seen    map[string]bool
+}
+
+// Creates a decoder that reads JSON values from the input.
+func newJSONDecoder(in io.Reader) *jsonDecoder {
+	dec := json.NewDecoder(in)
+	dec.UseNumber()
+
+	return &jsonDecoder{dec: dec, seen: make(map[string]bool)}
+}
+
+// Decodes the next value into a nushell value. Numbers are converted to
+// int64, if possible, and to float64 otherwise. The depth is the number of
+// lists and objects the value is nested in.
+func (d *jsonDecoder) value(depth int) (nu.Value, error) {
+	token, err := d.dec.Token()
+	if err != nil {
+		return nu.Value{}, err
+	}
+
+	switch t := token.(type) {
+	case json.Delim:
+		if t == '[' {
+			list := make([]nu.Value, 0)
+			for d.dec.More() {
+				item, err := d.value(depth + 1)
+				if err != nil {
+					return nu.Value{}, err
+				}
+				list = append(list, item)
+			}
+			_, err := d.dec.Token()
+			return nu.Value{Value: list}, err
+		}
+
+		record := make(nu.Record)
+		for d.dec.More() {
+			token, err := d.dec.Token()
+			if err != nil {
+				return nu.Value{}, err
+			}
+			key := token.(string)
+			if depth <= 1 && !d.seen[key] {
+				d.seen[key] = true
+				d.columns = append(d.columns, key)
+			}
+
+			if record[key], err = d.value(depth + 1); err != nil {
+				return nu.Value{}, err
+			}
+		}
+		_, err := d.dec.Token()
+		return nu.Value{Value: record}, err
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return nu.Value{Value: i}, nil
+		}
+		f, _ := t.Float64()
+		return nu.Value{Value: f}, nil
+	default:
+		// strings, bools and null
+		return nu.Value{Value: t}, nil
+	}
+}
+
+// Returns the delimiter of CSV input, that occurs most often in the first
+// line. Tabs are used for TSV, semicolons for CSV from some spreadsheets.
+func sniffDelimiter(head []byte) rune {
+	line, _, _ := bytes.Cut(head, []byte("\n"))
+
+	delimiter, count := ',', bytes.Count(line, []byte(","))
+	for _, d := range []rune{'\t', ';', '|'} {
+		if c := bytes.Count(line, []byte(string(d))); c > count {
+			delimiter, count = d, c
+		}
+	}
+
+	return delimiter
+}
+
+// Decodes a CSV table with the given delimiter into a list of records and
+// returns the column names. The first row is used as header, if it looks like
+// one, see [isHeaderRow]. Otherwise the columns are named column0, column1,
+// and so on. The types of the cells are inferred, see [inferCellValue].
+func decodeCSVInput(in io.Reader, delimiter rune, loc *time.Location) ([]nu.Value, []string, error) {
+	reader := csv.NewReader(in)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("decodeCSVInput: %w", err)
+	}
+	if len(rows) == 0 {
+		return []nu.Value{}, nil, nil
+	}
+
+	// Text without delimiters is only read as a single column of numbers or
+	// dates with an optional header, so that plain text is not plotted as a
+	// table of strings.
+	if !slices.ContainsFunc(rows, func(row []string) bool { return len(row) > 1 }) {
+		data := rows
+		if len(rows) > 1 {
+			data = rows[1:]
+		}
+		for _, row := range data {
+			if len(row) > 0 && isTextCell(row[0], loc) {
+				return nil, nil, fmt.Errorf("decodeCSVInput: input is neither JSON nor a delimited table, %q is not a number", row[0])
+			}
+		}
+	}
+
+	var header []string
+	if isHeaderRow(rows, loc) {
+		header = rows[0]
+		rows = rows[1:]
+	}
+	slog.Debug("decodeCSVInput", "rows", len(rows), "header", header)
+
+	columns := make([]string, 0, len(header))
+	columnName := func(j int) string {
+		for len(columns) <= j {
+			name := "column" + strconv.Itoa(len(columns))
+			if len(columns) < len(header) && strings.TrimSpace(header[len(columns)]) != "" {
+				name = strings.TrimSpace(header[len(columns)])
+			}
+			columns = append(columns, name)
+		}
+		return columns[j]
+	}
+
+	table := make([]nu.Value, len(rows))
+	for i, row := range rows {
+		record := make(nu.Record, len(row))
+		for j, cell := range row {
+			record[columnName(j)] = inferCellValue(cell, loc)
+		}
+		table[i] = nu.Value{Value: record}
+	}
+
+	return table, columns, nil
+}
+
+// Decides if the first row of a CSV table is a header. This is the case, if
+// none of its cells holds a number or a date, or if a column holds text in the
+// first row, but a number or a date in the second row.
+func isHeaderRow(rows [][]string, loc *time.Location) bool {
+	isText := func(cell string) bool { return isTextCell(cell, loc) }
+
+	first := rows[0]
+	if !slices.ContainsFunc(first, func(cell string) bool { return !isText(cell) && strings.TrimSpace(cell) != "" }) {
+		return true
+	}
+	if len(rows) == 1 {
+		return false
+	}
+
+	for i, cell := range first {
+		if i < len(rows[1]) && isText(cell) && !isText(rows[1][i]) && strings.TrimSpace(rows[1][i]) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true, if the CSV cell holds text, that is neither empty nor a number,
+// a bool or a date.
+func isTextCell(cell string, loc *time.Location) bool {
+	_, ok := inferCellValue(cell, loc).Value.(string)
+	return ok
+}
+
+// Infers the type of a CSV cell. Empty cells are null, numbers are converted
+// to int64 or float64, true/false to bool and date strings to dates, see
+// [matchXValueInLocation]. All other cells are kept as strings.
+func inferCellValue(cell string, loc *time.Location) nu.Value {
+	cell = strings.TrimSpace(cell)
+
+	if cell == "" {
+		return nu.Value{Value: nil}
+	}
+	if i, err := strconv.ParseInt(cell, 10, 64); err == nil {
+		return nu.Value{Value: i}
+	}
+	if f, err := strconv.ParseFloat(cell, 64); err == nil {
+		return nu.Value{Value: f}
+	}
+	switch strings.ToLower(cell) {
+	case "true":
+		return nu.Value{Value: true}
+	case "false":
+		return nu.Value{Value: false}
+	}
+	if date, ok := matchXValueInLocation(nu.Value{Value: cell}, loc).(time.Time); ok {
+		return nu.Value{Value: date}
+	}
+	return nu.Value{Value: cell}
+}
@@ -0,0 +1,137 @@
+package commands
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ainvaltin/nu-plugin"
+)
+
+// Builds a list of records from maps of plain values.
+func records(rows ...map[string]any) []nu.Value {
+	res := make([]nu.Value, len(rows))
+	for i, row := range rows {
+		record := make(nu.Record, len(row))
+		for k, v := range row {
+			record[k] = nu.Value{Value: v}
+		}
+		res[i] = nu.Value{Value: record}
+	}
+	return res
+}
+
+func TestDecodeRawInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    any
+		columns []string
+		wantErr bool
+	}{
+		{
+			name:    "empty",
+			input:   " \n",
+			want:    []nu.Value{},
+			columns: nil,
+		},
+		{
+			name:    "csv with header",
+			input:   "x,y\n1,2.5\n2,\n",
+			want:    records(map[string]any{"x": int64(1), "y": 2.5}, map[string]any{"x": int64(2), "y": nil}),
+			columns: []string{"x", "y"},
+		},
+		{
+			name:    "tsv without header",
+			input:   "\xef\xbb\xbf1\t2\n3\t4\n",
+			want:    records(map[string]any{"column0": int64(1), "column1": int64(2)}, map[string]any{"column0": int64(3), "column1": int64(4)}),
+			columns: []string{"column0", "column1"},
+		},
+		{
+			name:    "single column with header",
+			input:   "value\n1\n2\n",
+			want:    records(map[string]any{"value": int64(1)}, map[string]any{"value": int64(2)}),
+			columns: []string{"value"},
+		},
+		{
+			name:    "plain text",
+			input:   "hello world\nthis is no table\n",
+			wantErr: true,
+		},
+		{
+			name:    "json array",
+			input:   `[{"b": 1, "a": true}]`,
+			want:    records(map[string]any{"b": int64(1), "a": true}),
+			columns: []string{"b", "a"},
+		},
+		{
+			name:    "json record of lists",
+			input:   "{\n  \"a\": [1, 2],\n  \"b\": [3.5]\n}\n",
+			want:    records(map[string]any{"a": int64(1), "b": 3.5}, map[string]any{"a": int64(2), "b": nil}),
+			columns: []string{"a", "b"},
+		},
+		{
+			name:    "json record",
+			input:   "{\n  \"apples\": 7,\n  \"pears\": 5\n}",
+			want:    nu.Record{"apples": {Value: int64(7)}, "pears": {Value: int64(5)}},
+			columns: []string{"apples", "pears"},
+		},
+		{
+			name:    "minified record of lists",
+			input:   `{"a":[1,2],"b":[3,4]}`,
+			want:    records(map[string]any{"a": int64(1), "b": int64(3)}, map[string]any{"a": int64(2), "b": int64(4)}),
+			columns: []string{"a", "b"},
+		},
+		{
+			name:    "minified record",
+			input:   "{\"apples\":7}\n",
+			want:    nu.Record{"apples": {Value: int64(7)}},
+			columns: []string{"apples"},
+		},
+		{
+			name:    "minified table",
+			input:   `[{"a":1},{"a":2}]`,
+			want:    records(map[string]any{"a": int64(1)}, map[string]any{"a": int64(2)}),
+			columns: []string{"a"},
+		},
+		{
+			name:    "ndjson with a record of lists",
+			input:   "{\"a\": [1, 2]}\n{\"a\": [3]}\n",
+			want:    nuValues(nu.Record{"a": {Value: nuValues(int64(1), int64(2))}}, nu.Record{"a": {Value: nuValues(int64(3))}}),
+			columns: []string{"a"},
+		},
+		{
+			name:    "ndjson",
+			input:   "{\"a\": 1}\n{\"a\": 2}\n",
+			want:    records(map[string]any{"a": int64(1)}, map[string]any{"a": int64(2)}),
+			columns: []string{"a"},
+		},
+		{
+			name:    "invalid json",
+			input:   `[{"a": 1}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, columns, err := decodeRawInput(strings.NewReader(tt.input), time.UTC)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %#v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+			if !reflect.DeepEqual(columns, tt.columns) {
+				t.Errorf("got columns %q, want %q", columns, tt.columns)
+			}
+		})
+	}
+}
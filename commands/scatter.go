@@ -0,0 +1,252 @@
+package commands
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"slices"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+
+	"github.com/ainvaltin/nu-plugin"
+	"github.com/ainvaltin/nu-plugin/types"
+
+	"github.com/gtnebel/nu_plugin_nuplot/commands/flags"
+)
+
+// A list of scatter chart data points
+type ScatterDataList = []opts.ScatterData
+
+// This function initializes the nuplot scatter command.
+func NuplotScatter() *nu.Command {
+	return &nu.Command{
+		Signature: nu.PluginSignature{
+			Name:        "nuplot scatter",
+			Category:    "Chart",
+			Desc:        "Plots a scatter chart",
+			Description: "Title, size and color theme can be configured by flags. Each column that contains numbers will be plotted against the column given by the --xaxis flag. Numeric x values are placed on a value axis, so the rows do not need to be sorted.",
+			SearchTerms: []string{"plot", "graph", "scatter", "points"},
+			Named: []nu.Flag{
+				flags.XAxis,
+				flags.GroupBy,
+				flags.Y,
+				flags.Exclude,
+				flags.Agg,
+				flags.Missing,
+				flags.Trend,
+				flags.TrendOnly,
+				flags.XFormat,
+				flags.Timezone,
+				flags.Facet,
+				flags.FacetScales,
+				flags.FacetColumns,
+				flags.XName,
+				flags.YName,
+				flags.XMin,
+				flags.XMax,
+				flags.YMin,
+				flags.YMax,
+				flags.XLog,
+				flags.YLog,
+				flags.LogBase,
+				flags.Title,
+				flags.SubTitle,
+				flags.Width,
+				flags.Height,
+				flags.ColorTheme,
+				flags.Fitted,
+				flags.Verbose,
+			},
+			InputOutputTypes: []nu.InOutTypes{
+				{In: types.Table(types.RecordDef{}), Out: types.Nothing()},
+				{In: types.Table(types.RecordDef{}), Out: types.Record(types.RecordDef{})},
+				{In: types.Record(types.RecordDef{}), Out: types.Nothing()},
+				{In: types.Record(types.RecordDef{}), Out: types.Record(types.RecordDef{})},
+				{In: types.String(), Out: types.Nothing()},
+				{In: types.Binary(), Out: types.Nothing()},
+				{In: types.List(types.Number()), Out: types.Nothing()},
+			},
+			AllowMissingExamples: true,
+		},
+		Examples: []nu.Example{
+			{
+				Description: `Plot the height against the weight of a group of people.`,
+				Example:     `[[weight height]; [62 171] [80 185] [55 160] [71 178]] | nuplot scatter --xaxis weight`,
+			},
+			{
+				Description: `Plot two series from a record of lists, using the first one as x-axis.`,
+				Example:     `{x: [3 1 2 5], a: [9 1 4 25], b: [5 2 3 8]} | nuplot scatter --xaxis x`,
+			},
+			{
+				Description: `Plot response times against the request size with a quadratic trend.`,
+				Example:     `open --raw requests.csv | nuplot scatter --xaxis size --y duration --trend poly:2`,
+			},
+		},
+		OnRun: nuplotScatterHandler,
+	}
+}
+
+func nuplotScatterHandler(ctx context.Context, call *nu.ExecCommand) error {
+	checkVerboseFlag(call)
+
+	if getBoolFlag(call, flags.TrendOnly.Long) {
+		return handleTableInput(call, func(input any, call *nu.ExecCommand) error {
+			return returnLineTrends(ctx, input, call)
+		})
+	}
+	return handleTableInput(call, withFacets(plotScatter, buildScatter))
+}
+
+// Chooses the type of the x-axis of a scatter chart and returns the positions
+// of the points along with the options for the axis. Unlike on line charts,
+// numbers are always placed on a value axis and rows without x values at
+// their index. Dates are plotted on a time axis. For all other x values nil
+// is returned and the x values are plotted as categories.
+func (t *numericTable) scatterPositions(call *nu.ExecCommand) ([]float64, []charts.GlobalOpts, error) {
+	positions, xAxisOpts, err := t.xAxisOptions(call)
+	if err != nil || positions != nil {
+		return positions, xAxisOpts, err
+	}
+
+	if t.XAxisName == XAxisSeries {
+		positions, _ = t.trendX()
+	} else if positions = t.numericPositions(); positions == nil {
+		return nil, nil, nil
+	}
+
+	return positions, []charts.GlobalOpts{withXAxisChange(func(xAxis *opts.XAxis) {
+		xAxis.Type = "value"
+	})}, nil
+}
+
+// Converts a list of floats to scatter chart data points. If positions are
+// given, the points hold their position on the x-axis and missing values are
+// left out. Otherwise the points are placed on the categories of the x-axis
+// and missing values are kept as "-", so that the points stay aligned.
+func float64ToScatterData(positions []float64, values []float64) ScatterDataList {
+	res := make(ScatterDataList, 0, len(values))
+
+	for i, v := range values {
+		switch {
+		case positions == nil && math.IsNaN(v):
+			res = append(res, opts.ScatterData{Value: "-"})
+		case positions == nil:
+			res = append(res, opts.ScatterData{Value: v})
+		case !math.IsNaN(v) && !math.IsNaN(positions[i]):
+			res = append(res, opts.ScatterData{Value: []float64{positions[i], v}})
+		}
+	}
+
+	return res
+}
+
+// Draws the trend of a series as dashed line over the points. The rows of a
+// scatter chart need not be sorted, so the points of the trend are sorted by
+// their position first.
+func addScatterTrend(scatter *charts.Scatter, name string, positions []float64, values []float64) {
+	data := float64ToLineData(values)
+	if positions != nil {
+		rows := make([]int, 0, len(values))
+		for i, v := range values {
+			if !math.IsNaN(v) && !math.IsNaN(positions[i]) {
+				rows = append(rows, i)
+			}
+		}
+		slices.SortStableFunc(rows, func(a, b int) int {
+			return cmp.Compare(positions[a], positions[b])
+		})
+
+		data = make(LineDataList, len(rows))
+		for i, row := range rows {
+			data[i] = opts.LineData{Value: []float64{positions[row], values[row]}}
+		}
+	}
+
+	line := charts.NewLine()
+	line.AddSeries(name, data,
+		charts.WithLineChartOpts(opts.LineChart{ShowSymbol: opts.Bool(false)}),
+		charts.WithLineStyleOpts(opts.LineStyle{Type: "dashed"}),
+	)
+	scatter.Overlap(line)
+}
+
+func buildScatter(input any, call *nu.ExecCommand) (*charts.Scatter, valueRange, error) {
+	table, ok := input.(*numericTable)
+	if !ok {
+		return nil, valueRange{}, fmt.Errorf("plotScatter: unsupported input value type: %T", input)
+	}
+	slog.Debug("plotScatter", "xAxisName", table.XAxisName)
+
+	seriesNames, err := table.selectColumns(call)
+	if err != nil {
+		return nil, valueRange{}, fmt.Errorf("plotScatter: %w", err)
+	}
+	if err := table.applyMissingPolicy(call, seriesNames); err != nil {
+		return nil, valueRange{}, fmt.Errorf("plotScatter: %w", err)
+	}
+
+	trendColumns, err := table.addTrendColumns(call, seriesNames)
+	if err != nil {
+		return nil, valueRange{}, err
+	}
+
+	// create a new scatter instance
+	scatter := charts.NewScatter()
+
+	scatter.SetGlobalOptions(buildGlobalChartOptions(call)...)
+
+	axisOpts, err := buildAxisOptions(call)
+	if err != nil {
+		return nil, valueRange{}, err
+	}
+	scatter.SetGlobalOptions(axisOpts...)
+
+	positions, xAxisOpts, err := table.scatterPositions(call)
+	if err != nil {
+		return nil, valueRange{}, err
+	}
+	if positions != nil {
+		scatter.SetGlobalOptions(xAxisOpts...)
+	} else {
+		scatter = scatter.SetXAxis(table.xValues())
+	}
+
+	yValues := make([][]float64, 0, len(seriesNames))
+	for _, sName := range seriesNames {
+		yValues = append(yValues, table.Columns[sName])
+	}
+	if err := checkLogAxisValues(call, flags.YLog, yValues...); err != nil {
+		return nil, valueRange{}, err
+	}
+
+	// Put data into instance
+	plotted := newValueRange()
+	for _, sName := range seriesNames {
+		slog.Debug("plotScatter: Adding items to series", "series", sName, "items", table.Rows)
+		plotted.add(table.Columns[sName]...)
+		scatter = scatter.AddSeries(sName, float64ToScatterData(positions, table.Columns[sName]))
+
+		if tName, ok := trendColumns[sName]; ok {
+			plotted.add(table.Columns[tName]...)
+			addScatterTrend(scatter, tName, positions, table.Columns[tName])
+		}
+	}
+	scatter.SetGlobalOptions(withValueAxisKind(commonKind(table.Kinds, seriesNames), false))
+
+	setPageTitle(call, &scatter.BaseConfiguration)
+
+	return scatter, plotted, nil
+}
+
+func plotScatter(input any, call *nu.ExecCommand) error {
+	scatter, _, err := buildScatter(input, call)
+	if err != nil {
+		return err
+	}
+
+	return renderChart(func(f *os.File) error { return scatter.Render(f) })
+}
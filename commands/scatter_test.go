@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+
+	"github.com/ainvaltin/nu-plugin"
+)
+
+func TestFloat64ToScatterData(t *testing.T) {
+	nan := math.NaN()
+
+	tests := []struct {
+		name      string
+		positions []float64
+		values    []float64
+		want      ScatterDataList
+	}{
+		{
+			name:   "categories keep gaps",
+			values: []float64{1, nan, 3},
+			want:   ScatterDataList{{Value: 1.0}, {Value: "-"}, {Value: 3.0}},
+		},
+		{
+			name:      "positions leave out gaps",
+			positions: []float64{5, 2, nan, 4},
+			values:    []float64{1, nan, 3, 4},
+			want:      ScatterDataList{{Value: []float64{5, 1}}, {Value: []float64{4, 4}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := float64ToScatterData(tt.positions, tt.values); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildScatter(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []nu.Value
+		xAxis string
+		// The data of the first series
+		want ScatterDataList
+	}{
+		{
+			name: "unsorted numeric x values",
+			input: records(
+				map[string]any{"x": int64(3), "y": int64(9)},
+				map[string]any{"x": int64(1), "y": int64(1)},
+				map[string]any{"x": int64(2), "y": int64(4)},
+			),
+			xAxis: "x",
+			want:  ScatterDataList{{Value: []float64{3, 9}}, {Value: []float64{1, 1}}, {Value: []float64{2, 4}}},
+		},
+		{
+			name:  "row index",
+			input: nuValues(int64(5), int64(7)),
+			xAxis: XAxisSeries,
+			want:  ScatterDataList{{Value: []float64{0, 5}}, {Value: []float64{1, 7}}},
+		},
+		{
+			name: "categories",
+			input: records(
+				map[string]any{"x": "a", "y": int64(2)},
+				map[string]any{"x": "b", "y": nil},
+			),
+			xAxis: "x",
+			want:  ScatterDataList{{Value: 2.0}, {Value: "-"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, err := readNumericTable(tt.input, tt.xAxis, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			scatter, plotted, err := buildScatter(table, &nu.ExecCommand{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(scatter.MultiSeries) != 1 {
+				t.Fatalf("got %d series, want 1", len(scatter.MultiSeries))
+			}
+			if got := scatter.MultiSeries[0].Data; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			if plotted.Min > plotted.Max {
+				t.Errorf("got an empty range of plotted values")
+			}
+		})
+	}
+}
+
+func TestAddScatterTrend(t *testing.T) {
+	scatter := charts.NewScatter()
+	addScatterTrend(scatter, "trend", []float64{3, 1, math.NaN(), 2}, []float64{6, 2, 0, 4})
+
+	want := LineDataList{{Value: []float64{1, 2}}, {Value: []float64{2, 4}}, {Value: []float64{3, 6}}}
+	if got := scatter.MultiSeries[0].Data; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,555 @@
+package commands
+
+import (
+	"cmp"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ainvaltin/nu-plugin"
+
+	"github.com/gtnebel/nu_plugin_nuplot/commands/flags"
+)
+
+// List of all available policies for missing values
+var MissingPolicies = []string{"gap", "zero", "interpolate", "drop-row"}
+
+// Type of the values of an [xColumn].
+type xKind int
+
+const (
+	// No values were read yet
+	xNone xKind = iota
+	// Int or float values, stored as float64
+	xNumbers
+	// Dates
+	xDates
+	// Strings and values of mixed types
+	xOther
+)
+
+// The x values of a table. The values are stored in a typed slice as long as
+// all of them share the same type, so that large inputs do not need a boxed
+// value per row. Only the slice that belongs to Kind is used.
+type xColumn struct {
+	Kind    xKind
+	Numbers []float64
+	Dates   []time.Time
+	Other   []any
+}
+
+// Appends a value, as returned by [matchXValueInLocation], to the column. A
+// value that does not match the type of the column turns it into an xOther
+// column.
+func (c *xColumn) append(value any) {
+	kind := xOther
+	switch value.(type) {
+	case int64, float64:
+		kind = xNumbers
+	case time.Time:
+		kind = xDates
+	}
+
+	if c.Kind == xNone {
+		c.Kind = kind
+	} else if c.Kind != kind && c.Kind != xOther {
+		c.Other = c.values()
+		c.Numbers, c.Dates = nil, nil
+		c.Kind = xOther
+	}
+
+	switch c.Kind {
+	case xNumbers:
+		f, _ := ValueToFloat64(nu.Value{Value: value})
+		c.Numbers = append(c.Numbers, f)
+	case xDates:
+		c.Dates = append(c.Dates, value.(time.Time))
+	default:
+		c.Other = append(c.Other, value)
+	}
+}
+
+// Returns the number of values in the column.
+func (c *xColumn) len() int {
+	switch c.Kind {
+	case xNumbers:
+		return len(c.Numbers)
+	case xDates:
+		return len(c.Dates)
+	default:
+		return len(c.Other)
+	}
+}
+
+// Returns the values of the column as boxed values, e.g. for the categories
+// of an x-axis.
+func (c *xColumn) values() []any {
+	switch c.Kind {
+	case xNumbers:
+		res := make([]any, len(c.Numbers))
+		for i, v := range c.Numbers {
+			res[i] = v
+		}
+		return res
+	case xDates:
+		res := make([]any, len(c.Dates))
+		for i, v := range c.Dates {
+			res[i] = v
+		}
+		return res
+	default:
+		return c.Other
+	}
+}
+
+// Keeps only the values of the given rows.
+func (c *xColumn) keep(rows []int) {
+	switch c.Kind {
+	case xNumbers:
+		c.Numbers = keepRows(c.Numbers, rows)
+	case xDates:
+		c.Dates = keepRows(c.Dates, rows)
+	default:
+		c.Other = keepRows(c.Other, rows)
+	}
+}
+
+// Returns the values of the given rows.
+func keepRows[T any](values []T, rows []int) []T {
+	res := make([]T, len(rows))
+	for i, row := range rows {
+		res[i] = values[row]
+	}
+	return res
+}
+
+// The numeric columns of an input table. Every column holds exactly one value
+// per row, so that all values stay aligned with the x-axis. Missing values,
+// nulls and values that are not numbers are stored as NaN. Filesizes,
+// durations and dates are stored as numbers, see [valueToFloat64Kind].
+type numericTable struct {
+	// Name of the x-axis column or [XAxisSeries], if there is none.
+	XAxisName string
+	// The x values of all rows, only filled if XAxisName is a column.
+	X xColumn
+	// The names of the input columns in the order in which they first
+	// appear, see [tableReader.addNames].
+	Names []string
+	// The numeric columns by name.
+	Columns map[string][]float64
+	// The kind of the values of each column.
+	Kinds map[string]valueKind
+	// The number of rows of the table.
+	Rows int
+}
+
+// Reads the rows of an input table one by one into a [numericTable]. Only the
+// numeric columns and the x values are kept, so that the input values do not
+// have to be buffered.
+type tableReader struct {
+	table *numericTable
+	loc   *time.Location
+	// Nested cell paths that are resolved for each row, see
+	// [resolveRecordPaths].
+	paths map[string]cellPath
+	// The expected number of rows, used as capacity of new columns.
+	sizeHint int
+	// The positions of the column names of the input, if they are known,
+	// e.g. from the header of a CSV table.
+	order map[string]int
+	// Buffer for the names of the new columns of a row.
+	newNames []string
+}
+
+// Creates a reader for a table with the given x-axis column. Date strings in
+// the x-axis column are interpreted in the given location, see
+// [matchXValueInLocation]. The column names in order, if given, determine
+// the order of the columns, see [tableReader.addNames].
+func newTableReader(xAxisName string, loc *time.Location, paths map[string]cellPath, sizeHint int, order []string) *tableReader {
+	r := &tableReader{
+		table: &numericTable{
+			XAxisName: xAxisName,
+			Columns:   make(map[string][]float64),
+			Kinds:     make(map[string]valueKind),
+		},
+		loc:      loc,
+		paths:    paths,
+		sizeHint: sizeHint,
+	}
+	if len(order) > 0 {
+		r.order = make(map[string]int, len(order))
+		for i, name := range order {
+			r.order[name] = i
+		}
+	}
+	return r
+}
+
+// Adds the names of the new columns of a row to the names of the table. The
+// columns of a record have no order, so they are sorted by their position in
+// the input, if it is known, and by name otherwise.
+func (r *tableReader) addNames(names []string) {
+	slices.SortFunc(names, func(a, b string) int {
+		ia, aKnown := r.order[a]
+		ib, bKnown := r.order[b]
+		switch {
+		case aKnown && bKnown:
+			return cmp.Compare(ia, ib)
+		case aKnown:
+			return -1
+		case bKnown:
+			return 1
+		default:
+			return strings.Compare(a, b)
+		}
+	})
+	r.table.Names = append(r.table.Names, names...)
+}
+
+// Returns the column with the given name. A new column is filled with NaN
+// for all rows that were read before.
+func (r *tableReader) column(name string) []float64 {
+	if c, ok := r.table.Columns[name]; ok {
+		return c
+	}
+
+	c := make([]float64, r.table.Rows, max(r.sizeHint, r.table.Rows))
+	for i := range c {
+		c[i] = math.NaN()
+	}
+	return c
+}
+
+// Adds a row to the table. The row can be a number, which is read into the
+// [DefaultSeries] column, or a record.
+func (r *tableReader) add(item nu.Value) error {
+	table := r.table
+	itemIndex := table.Rows
+
+	switch itemValue := item.Value.(type) {
+	case int64, float64, nu.Filesize, time.Duration, time.Time:
+		v, kind, _ := valueToFloat64Kind(item)
+		if _, ok := table.Columns[DefaultSeries]; !ok {
+			r.addNames([]string{DefaultSeries})
+		}
+		table.Columns[DefaultSeries] = append(r.column(DefaultSeries), v)
+		table.Kinds[DefaultSeries] = kind
+	case nu.Record:
+		if len(r.paths) > 0 {
+			if err := resolveRecordPaths(itemValue, r.paths); err != nil {
+				return fmt.Errorf("row %d: %w", itemIndex, err)
+			}
+		}
+
+		// Try to set xAxisName to one of the columns in the record.
+		if itemIndex == 0 {
+			table.XAxisName = autoSetXaxis(itemValue, table.XAxisName)
+		}
+
+		newNames := r.newNames[:0]
+		for k, v := range itemValue {
+			if k == table.XAxisName {
+				continue
+			}
+			if f, kind, err := valueToFloat64Kind(v); err == nil {
+				if _, ok := table.Columns[k]; !ok {
+					newNames = append(newNames, k)
+				}
+				table.Columns[k] = append(r.column(k), f)
+				table.Kinds[k] = kind
+			}
+		}
+		if len(newNames) > 0 {
+			r.addNames(newNames)
+		}
+		r.newNames = newNames
+
+		if table.XAxisName != XAxisSeries {
+			if v, ok := itemValue[table.XAxisName]; ok {
+				table.X.append(matchXValueInLocation(v, r.loc))
+			} else {
+				slog.Warn("Specified x-axis is not continuous. Reseting x-axis to default value.")
+				// If the column specified in --xaxis does not exist, we
+				// set the `XAxisName` to XAxisSeries, so that a simple int
+				// range is generated as x axis.
+				table.XAxisName = XAxisSeries
+				table.X = xColumn{}
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported input value type %T in row %d", itemValue, itemIndex)
+	}
+
+	// Columns that are missing in this row get a NaN value.
+	table.Rows++
+	for k, c := range table.Columns {
+		if len(c) < table.Rows {
+			table.Columns[k] = append(c, math.NaN())
+		}
+	}
+
+	return nil
+}
+
+// Reads the numeric columns of the input. The input can be a list of numbers,
+// which is read into the [DefaultSeries] column, or a table. Date strings in
+// the x-axis column are interpreted in the given location, see
+// [matchXValueInLocation]. The order of the columns of the input is used, if
+// it is known, see [newTableReader].
+func readNumericTable(input []nu.Value, xAxisName string, loc *time.Location, order []string) (*numericTable, error) {
+	reader := newTableReader(xAxisName, loc, nil, len(input), order)
+	for _, item := range input {
+		if err := reader.add(item); err != nil {
+			return nil, fmt.Errorf("readNumericTable: %w", err)
+		}
+	}
+
+	return reader.table, nil
+}
+
+// Like [readNumericTable], but reads the rows directly from the input stream.
+// Nested cell paths of the flags are resolved for each row, see
+// [resolveCellPaths].
+func readNumericTableStream(input <-chan nu.Value, xAxisName string, loc *time.Location, paths map[string]cellPath) (*numericTable, error) {
+	reader := newTableReader(xAxisName, loc, paths, 0, nil)
+	for item := range input {
+		if err := reader.add(item); err != nil {
+			// The rest of the stream has to be consumed, so that the
+			// sender is not blocked.
+			for range input {
+			}
+			return nil, fmt.Errorf("readNumericTableStream: %w", err)
+		}
+	}
+	slog.Debug("readNumericTableStream", "rows", reader.table.Rows, "columns", len(reader.table.Columns))
+
+	return reader.table, nil
+}
+
+// Reads buffered input rows into a [numericTable]. The rows are collapsed by
+// --agg and pivoted by --group-by first, see [aggregateRows] and
+// [pivotByGroup]. The order of the columns is used, if it is known.
+func readTableRows(rows []nu.Value, order []string, call *nu.ExecCommand, loc *time.Location) (*numericTable, error) {
+	input, err := aggregateRows(rows, call)
+	if err != nil {
+		return nil, err
+	}
+	if input, order, err = pivotByGroup(input, order, call); err != nil {
+		return nil, err
+	}
+
+	return readNumericTable(input.([]nu.Value), getCellPathFlag(call, flags.XAxis.Long, XAxisSeries), loc, order)
+}
+
+// Like [handleCommandInput], but reads the input into a [numericTable], which
+// is then passed to the plot function. With --facet, the plot function gets
+// a list of [facetGroup]s instead, each holding the table of one facet.
+//
+// Streamed input is read row by row, so that only the numeric columns and the
+// x values are kept in memory, see [tableReader]. This includes facets, each
+// row is read into the table of its facet. Aggregation and grouping need all
+// rows that share an x value, which may appear anywhere in the stream, so the
+// rows are buffered if --agg or --group-by is given.
+func handleTableInput(call *nu.ExecCommand, plotFunc PlotHandlerFunc) error {
+	loc, err := getTimezoneFlag(call)
+	if err != nil {
+		return err
+	}
+	xAxisName := getCellPathFlag(call, flags.XAxis.Long, XAxisSeries)
+	facetName := getCellPathFlag(call, flags.Facet.Long, "")
+
+	in, ok := call.Input.(<-chan nu.Value)
+	if !ok ||
+		getCellPathFlag(call, flags.GroupBy.Long, "") != "" ||
+		getStringFlag(call, flags.Agg.Long, "") != "" {
+		input, order, err := readCommandInput(call)
+		if err != nil || call.Input == nil {
+			return err
+		}
+		rows, ok := input.([]nu.Value)
+		if !ok {
+			// The plot function reports the unsupported input type.
+			return plotFunc(input, call)
+		}
+
+		if facetName == "" {
+			table, err := readTableRows(rows, order, call, loc)
+			if err != nil {
+				return err
+			}
+			return plotFunc(table, call)
+		}
+
+		groups, err := splitFacetGroups(rows, facetName)
+		if err != nil {
+			return err
+		}
+		for i := range groups {
+			if groups[i].Table, err = readTableRows(groups[i].Rows, order, call, loc); err != nil {
+				return fmt.Errorf("facet %q: %w", groups[i].Name, err)
+			}
+			groups[i].Rows = nil
+		}
+		return plotFunc(groups, call)
+	}
+	slog.Debug("handleTableInput: streaming input into table")
+
+	if facetName != "" {
+		groups, err := readFacetTablesStream(in, facetName, xAxisName, loc, nestedCellPaths(call))
+		if err != nil {
+			return err
+		}
+		return plotFunc(groups, call)
+	}
+
+	table, err := readNumericTableStream(in, xAxisName, loc, nestedCellPaths(call))
+	if err != nil {
+		return err
+	}
+
+	return plotFunc(table, call)
+}
+
+// Converts a record of lists, e.g. {a: [1 2 3], b: [4 5 6]}, into a table
+// with one column per list. Shorter lists are filled with nulls. All other
+// input is returned unchanged.
+func recordOfListsToTable(input any) any {
+	record, ok := input.(nu.Record)
+	if !ok || len(record) == 0 {
+		return input
+	}
+
+	rows := 0
+	for _, v := range record {
+		list, ok := v.Value.([]nu.Value)
+		if !ok {
+			return input
+		}
+		rows = max(rows, len(list))
+	}
+	slog.Debug("recordOfListsToTable", "columns", len(record), "rows", rows)
+
+	table := make([]nu.Value, rows)
+	for i := range rows {
+		row := make(nu.Record, len(record))
+		for k, v := range record {
+			if list := v.Value.([]nu.Value); i < len(list) {
+				row[k] = list[i]
+			} else {
+				row[k] = nu.Value{Value: nil}
+			}
+		}
+		table[i] = nu.Value{Value: row}
+	}
+
+	return table
+}
+
+// Returns the names of the columns that should be plotted in the order of the
+// input, see [selectNamedSeries]. Date columns are only plotted, if they are
+// given in --y.
+func (t *numericTable) selectColumns(call *nu.ExecCommand, skip ...string) ([]string, error) {
+	names := slices.DeleteFunc(slices.Clone(t.Names), func(name string) bool {
+		_, ok := t.Columns[name]
+		return !ok
+	})
+	names, err := selectNamedSeries(names, call, skip...)
+	if err != nil || len(getStringListFlag(call, flags.Y.Long)) > 0 {
+		return names, err
+	}
+
+	return slices.DeleteFunc(names, func(name string) bool {
+		return t.Kinds[name] == kindDate
+	}), nil
+}
+
+// Returns the x values of the table or a simple int range, if the table has
+// no x-axis column.
+func (t *numericTable) xValues() []any {
+	if t.XAxisName != XAxisSeries {
+		return t.X.values()
+	}
+
+	xRange := make([]any, t.Rows)
+	for i := range t.Rows {
+		xRange[i] = i
+	}
+	return xRange
+}
+
+// Handles the missing values of the given columns as requested by the
+// --missing flag.
+func (t *numericTable) applyMissingPolicy(call *nu.ExecCommand, names []string) error {
+	policy := getStringFlag(call, flags.Missing.Long, flags.Missing.Default.Value.(string))
+	slog.Debug("applyMissingPolicy", "policy", policy, "columns", names)
+
+	switch policy {
+	case "gap":
+		// NaN values are plotted as gaps.
+	case "zero":
+		for _, name := range names {
+			for i, v := range t.Columns[name] {
+				if math.IsNaN(v) {
+					t.Columns[name][i] = 0
+				}
+			}
+		}
+	case "interpolate":
+		for _, name := range names {
+			interpolateMissing(t.Columns[name])
+		}
+	case "drop-row":
+		t.dropRows(func(row int) bool {
+			return slices.ContainsFunc(names, func(name string) bool {
+				return math.IsNaN(t.Columns[name][row])
+			})
+		})
+	default:
+		return fmt.Errorf("invalid --missing %q, expected one of: %v", policy, MissingPolicies)
+	}
+
+	return nil
+}
+
+// Removes all rows for which drop returns true.
+func (t *numericTable) dropRows(drop func(row int) bool) {
+	keep := make([]int, 0, t.Rows)
+	for row := range t.Rows {
+		if !drop(row) {
+			keep = append(keep, row)
+		}
+	}
+	slog.Debug("dropRows", "rows", t.Rows, "kept", len(keep))
+
+	for k, c := range t.Columns {
+		t.Columns[k] = keepRows(c, keep)
+	}
+
+	if t.XAxisName != XAxisSeries {
+		t.X.keep(keep)
+	}
+
+	t.Rows = len(keep)
+}
+
+// Replaces NaN values between two numbers by linear interpolation. Missing
+// values at the start or the end of the data are left untouched.
+func interpolateMissing(data []float64) {
+	last := -1
+	for i, v := range data {
+		if math.IsNaN(v) {
+			continue
+		}
+
+		if last >= 0 && i-last > 1 {
+			step := (v - data[last]) / float64(i-last)
+			for j := last + 1; j < i; j++ {
+				data[j] = data[last] + step*float64(j-last)
+			}
+		}
+		last = i
+	}
+}
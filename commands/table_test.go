@@ -0,0 +1,269 @@
+package commands
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-echarts/go-echarts/v2/opts"
+
+	"github.com/ainvaltin/nu-plugin"
+)
+
+// Generates a table with an x column and two numeric columns.
+func generateRows(rows int) []nu.Value {
+	res := make([]nu.Value, rows)
+	for i := range res {
+		res[i] = nu.Value{Value: nu.Record{
+			"x": {Value: int64(i)},
+			"a": {Value: float64(i) * 0.5},
+			"b": {Value: int64(i % 1000)},
+		}}
+	}
+	return res
+}
+
+// Sends the rows through a channel like a list stream of nushell.
+func streamRows(rows []nu.Value) <-chan nu.Value {
+	ch := make(chan nu.Value, 64)
+	go func() {
+		for _, row := range rows {
+			ch <- row
+		}
+		close(ch)
+	}()
+	return ch
+}
+
+// Reads the rows like the line chart did before the rows were read into a
+// [numericTable]: the stream is buffered and every value is boxed into a
+// line chart data point in a map of series. The buffered rows are returned
+// as well, as they stayed in use until the chart was rendered.
+func readLineSeriesBuffered(in <-chan nu.Value, xAxisName string) ([]nu.Value, LineDataSeries) {
+	rows := make([]nu.Value, 0)
+	for v := range in {
+		rows = append(rows, v)
+	}
+
+	series := make(LineDataSeries)
+	for _, row := range rows {
+		for k, v := range row.Value.(nu.Record) {
+			if k == xAxisName {
+				series[k] = append(getSeries(series, k), opts.LineData{Value: matchXValue(v)})
+				continue
+			}
+			if _, err := ValueToFloat64(v); err == nil {
+				series[k] = append(getSeries(series, k), opts.LineData{Value: v.Value})
+			}
+		}
+	}
+	return rows, series
+}
+
+// Runs a reader of streamed rows as benchmark. Besides the allocations per
+// row, the heap that is still in use by the result of the reader is reported
+// per row. The rows are generated up front, so that only the memory of the
+// reader is measured.
+func benchmarkReader(b *testing.B, read func(rows <-chan nu.Value) int) {
+	for _, n := range []int{10_000, 100_000, 500_000} {
+		rows := generateRows(n)
+
+		b.Run(fmt.Sprintf("rows=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+
+			var stats runtime.MemStats
+			runtime.GC()
+			runtime.ReadMemStats(&stats)
+			before := stats.HeapAlloc
+			if got := read(streamRows(rows)); got != n {
+				b.Fatalf("got %d rows, want %d", got, n)
+			}
+			runtime.GC()
+			runtime.ReadMemStats(&stats)
+			retained := float64(stats.HeapAlloc) - float64(before)
+
+			var allocated, iterations uint64
+			for b.Loop() {
+				runtime.ReadMemStats(&stats)
+				before := stats.TotalAlloc
+
+				if got := read(streamRows(rows)); got != n {
+					b.Fatalf("got %d rows, want %d", got, n)
+				}
+
+				runtime.ReadMemStats(&stats)
+				allocated += stats.TotalAlloc - before
+				iterations++
+			}
+
+			b.ReportMetric(float64(allocated)/float64(iterations*uint64(n)), "B/row")
+			b.ReportMetric(retained/float64(n), "retained-B/row")
+		})
+	}
+}
+
+// The numeric table keeps only the numeric columns and the x values, so the
+// memory per row has to stay constant for any length of the stream.
+func BenchmarkReadNumericTableStream(b *testing.B) {
+	var table *numericTable
+	benchmarkReader(b, func(rows <-chan nu.Value) int {
+		var err error
+		if table, err = readNumericTableStream(rows, "x", time.UTC, nil); err != nil {
+			b.Fatal(err)
+		}
+		return table.Rows
+	})
+	runtime.KeepAlive(table)
+}
+
+// The previous path of the line chart for comparison, see
+// [readLineSeriesBuffered].
+func BenchmarkReadLineSeriesBuffered(b *testing.B) {
+	var buffered []nu.Value
+	var series LineDataSeries
+	benchmarkReader(b, func(rows <-chan nu.Value) int {
+		buffered, series = readLineSeriesBuffered(rows, "x")
+		return len(series["x"])
+	})
+	runtime.KeepAlive(buffered)
+	runtime.KeepAlive(series)
+}
+
+func TestReadNumericTableColumnOrder(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "csv header",
+			input: "time,zeta,alpha,mid\n1,2,3,4\n2,3,4,5\n",
+			want:  []string{"zeta", "alpha", "mid"},
+		},
+		{
+			name:  "json keys",
+			input: `[{"time": 1, "zeta": 2, "alpha": 3}, {"time": 2, "zeta": 3, "alpha": 4, "beta": 5}]`,
+			want:  []string{"zeta", "alpha", "beta"},
+		},
+		{
+			name:  "ndjson keys",
+			input: "{\"b\": 1, \"a\": 2}\n{\"c\": 3, \"b\": 4}\n",
+			want:  []string{"b", "a", "c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, order, err := decodeRawInput(strings.NewReader(tt.input), time.UTC)
+			if err != nil {
+				t.Fatal(err)
+			}
+			table, err := readNumericTable(value.([]nu.Value), "time", time.UTC, order)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(table.Names, tt.want) {
+				t.Errorf("got columns %q, want %q", table.Names, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadNumericTableColumnOrderWithoutHint(t *testing.T) {
+	// Records have no column order, so new columns are sorted by name and
+	// appended in the order of the rows.
+	input := []nu.Value{
+		{Value: nu.Record{"c": {Value: int64(1)}, "b": {Value: int64(2)}}},
+		{Value: nu.Record{"c": {Value: int64(1)}, "a": {Value: int64(3)}}},
+	}
+
+	table, err := readNumericTable(input, XAxisSeries, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"b", "c", "a"}; !slices.Equal(table.Names, want) {
+		t.Errorf("got columns %q, want %q", table.Names, want)
+	}
+}
+
+func TestReadNumericTableNullAlignment(t *testing.T) {
+	nan := math.NaN()
+
+	input := records(
+		map[string]any{"x": int64(1), "a": int64(1), "b": "n/a"},
+		map[string]any{"x": int64(2), "a": nil, "b": 2.5},
+		map[string]any{"x": int64(3), "c": int64(7)},
+		map[string]any{"x": int64(4), "a": int64(4), "b": 4.5, "c": nil},
+	)
+
+	table, err := readNumericTable(input, "x", time.UTC, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Rows != 4 {
+		t.Fatalf("got %d rows, want 4", table.Rows)
+	}
+	if got, want := table.X.Numbers, []float64{1, 2, 3, 4}; !slices.Equal(got, want) {
+		t.Errorf("got x values %v, want %v", got, want)
+	}
+
+	want := map[string][]float64{
+		"a": {1, nan, nan, 4},
+		"b": {nan, 2.5, nan, 4.5},
+		"c": {nan, nan, 7, nan},
+	}
+	for name, values := range want {
+		if got := table.Columns[name]; !equalFloats(got, values) {
+			t.Errorf("got column %q %v, want %v", name, got, values)
+		}
+	}
+}
+
+func TestDropRows(t *testing.T) {
+	table, err := readNumericTable(records(
+		map[string]any{"x": "a", "y": int64(1)},
+		map[string]any{"x": "b", "y": nil},
+		map[string]any{"x": "c", "y": int64(3)},
+	), "x", time.UTC, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table.dropRows(func(row int) bool { return math.IsNaN(table.Columns["y"][row]) })
+	if table.Rows != 2 {
+		t.Fatalf("got %d rows, want 2", table.Rows)
+	}
+	if got, want := table.xValues(), []any{"a", "c"}; !slices.Equal(got, want) {
+		t.Errorf("got x values %v, want %v", got, want)
+	}
+	if got, want := table.Columns["y"], []float64{1, 3}; !equalFloats(got, want) {
+		t.Errorf("got y values %v, want %v", got, want)
+	}
+}
+
+func TestInterpolateMissing(t *testing.T) {
+	nan := math.NaN()
+
+	tests := []struct {
+		name   string
+		values []float64
+		want   []float64
+	}{
+		{"inner gaps", []float64{1, nan, nan, 4, nan, 8}, []float64{1, 2, 3, 4, 6, 8}},
+		{"edges are kept", []float64{nan, 2, nan, 4, nan}, []float64{nan, 2, 3, 4, nan}},
+		{"only gaps", []float64{nan, nan}, []float64{nan, nan}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interpolateMissing(tt.values)
+			if !equalFloats(tt.values, tt.want) {
+				t.Errorf("got %v, want %v", tt.values, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+	charttypes "github.com/go-echarts/go-echarts/v2/types"
+
+	"github.com/ainvaltin/nu-plugin"
+
+	"github.com/gtnebel/nu_plugin_nuplot/commands/flags"
+)
+
+// Replacements of strftime directives, as used by `format date`, by the
+// placeholders of echarts time templates.
+var strftimeReplacer = strings.NewReplacer(
+	"%Y", "{yyyy}", "%y", "{yy}",
+	"%m", "{MM}", "%B", "{MMMM}", "%b", "{MMM}",
+	"%d", "{dd}", "%e", "{d}",
+	"%A", "{eeee}", "%a", "{ee}",
+	"%H", "{HH}", "%I", "{hh}", "%p", "{A}",
+	"%M", "{mm}", "%S", "{ss}", "%f", "{SSS}",
+	"%F", "{yyyy}-{MM}-{dd}", "%T", "{HH}:{mm}:{ss}", "%R", "{HH}:{mm}",
+	"%%", "%",
+)
+
+// Reads the --timezone flag. If the flag is not given, nil is returned and the
+// default interpretation of [matchXValue] is used.
+func getTimezoneFlag(call *nu.ExecCommand) (*time.Location, error) {
+	name := getStringFlag(call, flags.Timezone.Long, "")
+
+	switch strings.ToLower(name) {
+	case "":
+		return nil, nil
+	case "local":
+		return time.Local, nil
+	default:
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --timezone %q: %w", name, err)
+		}
+		return loc, nil
+	}
+}
+
+// Returns the positions of the rows on a time axis in milliseconds since
+// epoch, if all x values are dates. Otherwise nil is returned and the x values
+// are plotted on a category axis.
+func (t *numericTable) timePositions() []float64 {
+	if t.XAxisName == XAxisSeries || t.X.Kind != xDates {
+		return nil
+	}
+
+	positions := make([]float64, len(t.X.Dates))
+	for i, date := range t.X.Dates {
+		positions[i] = float64(date.UnixMilli())
+	}
+
+	return positions
+}
+
+// Returns a global option that turns the x-axis into a time axis. The labels
+// are formatted with the --x-format flag, which can be an echarts time
+// template or a strftime format string. The series data of a time axis has
+// to hold the positions of the data points, see [float64ToLineDataAt].
+func withTimeXAxis(call *nu.ExecCommand) charts.GlobalOpts {
+	format := strftimeReplacer.Replace(getStringFlag(call, flags.XFormat.Long, ""))
+
+	return withXAxisChange(func(xAxis *opts.XAxis) {
+		xAxis.Type = "time"
+
+		if format != "" {
+			if xAxis.AxisLabel == nil {
+				xAxis.AxisLabel = &opts.AxisLabel{}
+			}
+			xAxis.AxisLabel.Formatter = charttypes.FuncStr(format)
+		}
+	})
+}
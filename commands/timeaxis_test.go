@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ainvaltin/nu-plugin"
+)
+
+func TestStrftimeReplacer(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"%Y-%m-%d", "{yyyy}-{MM}-{dd}"},
+		{"%F %T", "{yyyy}-{MM}-{dd} {HH}:{mm}:{ss}"},
+		{"%d. %B %y", "{dd}. {MMMM} {yy}"},
+		{"%I:%M %p", "{hh}:{mm} {A}"},
+		{"100%% at %R", "100% at {HH}:{mm}"},
+		{"{yyyy}/{MM}", "{yyyy}/{MM}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			if got := strftimeReplacer.Replace(tt.format); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimePositions(t *testing.T) {
+	start := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	tests := []struct {
+		name string
+		x    []any
+		loc  *time.Location
+		want []float64
+	}{
+		{
+			name: "dates with uneven gaps",
+			x:    []any{start, start.Add(time.Hour), start.Add(25 * time.Hour)},
+			want: []float64{float64(start.UnixMilli()), float64(start.Add(time.Hour).UnixMilli()), float64(start.Add(25 * time.Hour).UnixMilli())},
+		},
+		{
+			name: "date strings in a location",
+			x:    []any{"2024-06-01 02:00:00", "2024-06-01 03:00:00"},
+			loc:  berlin,
+			want: []float64{float64(start.UnixMilli()), float64(start.Add(time.Hour).UnixMilli())},
+		},
+		{
+			name: "dates mixed with other values",
+			x:    []any{start, "later"},
+		},
+		{
+			name: "numbers",
+			x:    []any{int64(1), int64(2)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := make([]nu.Value, len(tt.x))
+			for i, x := range tt.x {
+				input[i] = nu.Value{Value: nu.Record{"x": {Value: x}, "y": {Value: int64(i)}}}
+			}
+
+			table, err := readNumericTable(input, "x", tt.loc, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := table.timePositions(); !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package commands
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+
+	"github.com/ainvaltin/nu-plugin"
+
+	"github.com/gtnebel/nu_plugin_nuplot/commands/flags"
+)
+
+// List of all available transforms of the series values
+var Transforms = []string{"cumsum", "diff", "rate", "pct-change", "normalize", "index100", "zscore"}
+
+// Applies the transform given in the --transform flag to the given columns.
+// The transforms are applied to each column on its own after all rows are
+// aligned, so missing values are handled before, see [applyMissingPolicy].
+// Missing values stay missing.
+func (t *numericTable) applyTransform(call *nu.ExecCommand, names []string) error {
+	transform := getStringFlag(call, flags.Transform.Long, "")
+	if transform == "" {
+		return nil
+	}
+	slog.Debug("applyTransform", "transform", transform, "columns", names)
+
+	var fn func(values []float64) error
+	switch transform {
+	case "cumsum":
+		fn = ignoreError(cumulativeSum)
+	case "diff":
+		fn = ignoreError(func(values []float64) { differences(values, nil) })
+	case "rate":
+		positions := t.ratePositions()
+		fn = ignoreError(func(values []float64) { differences(values, positions) })
+	case "pct-change":
+		fn = ignoreError(percentChange)
+	case "normalize":
+		fn = ignoreError(normalize)
+	case "index100":
+		fn = indexTo100
+	case "zscore":
+		fn = ignoreError(zScore)
+	default:
+		return fmt.Errorf("invalid --transform %q, expected one of: %v", transform, Transforms)
+	}
+
+	for _, name := range names {
+		if err := fn(t.Columns[name]); err != nil {
+			return fmt.Errorf("series %q: %w", name, err)
+		}
+
+		// Sums and differences keep their unit, all other transforms result
+		// in plain numbers. Differences of dates are no dates.
+		if (transform != "cumsum" && transform != "diff") || t.Kinds[name] == kindDate {
+			t.Kinds[name] = kindNumber
+		}
+	}
+
+	return nil
+}
+
+// List of all available functions of rolling windows
+var RollingFuncs = []string{"mean", "median", "max"}
+
+// Adds a column with the rolling window of the --rolling and --rolling-fn
+// flags for each of the given columns. The names of the new columns are
+// returned by the names of the columns they belong to.
+func (t *numericTable) addRollingColumns(call *nu.ExecCommand, names []string) (map[string]string, error) {
+	window := int(getIntFlag(call, flags.Rolling.Long, 0))
+	if window == 0 {
+		return nil, nil
+	}
+	if window < 1 {
+		return nil, flagError(call, flags.Rolling, fmt.Errorf("the window needs at least 1 value, got %d", window))
+	}
+
+	fnName := getStringFlag(call, flags.RollingFn.Long, flags.RollingFn.Default.Value.(string))
+	if !slices.Contains(RollingFuncs, fnName) {
+		return nil, flagError(call, flags.RollingFn, fmt.Errorf("invalid function %q, expected one of: %v", fnName, RollingFuncs))
+	}
+	fn := numericAggregateFuncs[fnName]
+	slog.Debug("addRollingColumns", "window", window, "fn", fnName, "columns", names)
+
+	res := make(map[string]string, len(names))
+	for _, name := range names {
+		rName := fmt.Sprintf("%s (rolling %s %d)", name, fnName, window)
+		t.Columns[rName] = rollingWindow(t.Columns[name], window, fn)
+		t.Kinds[rName] = t.Kinds[name]
+		res[name] = rName
+	}
+
+	return res, nil
+}
+
+// Wraps a transform that can not fail.
+func ignoreError(fn func(values []float64)) func(values []float64) error {
+	return func(values []float64) error {
+		fn(values)
+		return nil
+	}
+}
+
+// Returns the positions of the rows that are used by the rate transform.
+// Dates on the x-axis result in positions in seconds, numbers are used as
+// they are. All other x values are numbered, so that the rate is calculated
+// per row.
+func (t *numericTable) ratePositions() []float64 {
+	if positions := t.timePositions(); positions != nil {
+		seconds := make([]float64, len(positions))
+		for i, p := range positions {
+			seconds[i] = p / 1000
+		}
+		return seconds
+	}
+	if positions := t.numericPositions(); positions != nil {
+		return positions
+	}
+
+	rows := make([]float64, t.Rows)
+	for i := range rows {
+		rows[i] = float64(i)
+	}
+	return rows
+}
+
+// Replaces the values by their running sum.
+func cumulativeSum(values []float64) {
+	sum := 0.0
+	for i, v := range values {
+		if !math.IsNaN(v) {
+			sum += v
+			values[i] = sum
+		}
+	}
+}
+
+// Replaces the values by the difference to the previous value, that is not
+// missing. If positions are given, the differences are divided by the
+// distance of the positions. The first value has no previous value and
+// becomes NaN.
+func differences(values []float64, positions []float64) {
+	prev, prevRow := math.NaN(), -1
+	for i, v := range values {
+		if math.IsNaN(v) {
+			continue
+		}
+
+		values[i] = v - prev
+		if positions != nil && prevRow >= 0 {
+			if interval := positions[i] - positions[prevRow]; interval == 0 {
+				values[i] = math.NaN()
+			} else {
+				values[i] /= interval
+			}
+		}
+		prev, prevRow = v, i
+	}
+}
+
+// Replaces the values by their change to the previous value, that is not
+// missing, in percent.
+func percentChange(values []float64) {
+	prev := math.NaN()
+	for i, v := range values {
+		if math.IsNaN(v) {
+			continue
+		}
+
+		if prev == 0 {
+			values[i] = math.NaN()
+		} else {
+			values[i] = (v - prev) / math.Abs(prev) * 100
+		}
+		prev = v
+	}
+}
+
+// Scales the values linearly to the range from 0 to 1.
+func normalize(values []float64) {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			lo, hi = math.Min(lo, v), math.Max(hi, v)
+		}
+	}
+
+	for i, v := range values {
+		if hi > lo {
+			values[i] = (v - lo) / (hi - lo)
+		} else if !math.IsNaN(v) {
+			values[i] = 0
+		}
+	}
+}
+
+// Scales the values, so that the first value is 100. Leading zeros can not
+// be scaled, the first value that is neither missing nor zero is used
+// instead. An error is returned, if there is no such value.
+func indexTo100(values []float64) error {
+	i := slices.IndexFunc(values, func(v float64) bool { return v != 0 && !math.IsNaN(v) })
+	if i < 0 {
+		return fmt.Errorf("index100 needs a value that is not zero")
+	}
+	base := values[i]
+
+	for i, v := range values {
+		values[i] = v / base * 100
+	}
+	return nil
+}
+
+// Replaces the values by their standard score, the distance to the mean in
+// standard deviations.
+func zScore(values []float64) {
+	sum, count := 0.0, 0.0
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			sum += v
+			count++
+		}
+	}
+	mean := sum / count
+
+	variance := 0.0
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			variance += (v - mean) * (v - mean)
+		}
+	}
+	stddev := math.Sqrt(variance / count)
+
+	for i, v := range values {
+		if stddev > 0 {
+			values[i] = (v - mean) / stddev
+		} else if !math.IsNaN(v) {
+			values[i] = 0
+		}
+	}
+}
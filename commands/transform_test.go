@@ -0,0 +1,120 @@
+package commands
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDifferences(t *testing.T) {
+	nan := math.NaN()
+
+	tests := []struct {
+		name      string
+		values    []float64
+		positions []float64
+		want      []float64
+	}{
+		{
+			name:   "diff",
+			values: []float64{1, 3, 6, 10},
+			want:   []float64{nan, 2, 3, 4},
+		},
+		{
+			name:   "diff over gaps",
+			values: []float64{1, nan, 3, 6},
+			want:   []float64{nan, nan, 2, 3},
+		},
+		{
+			name:   "leading gap",
+			values: []float64{nan, 2, 5},
+			want:   []float64{nan, nan, 3},
+		},
+		{
+			name:      "rate",
+			values:    []float64{0, 10, 40},
+			positions: []float64{0, 2, 5},
+			want:      []float64{nan, 5, 10},
+		},
+		{
+			name:      "rate over gaps",
+			values:    []float64{0, nan, 40},
+			positions: []float64{0, 2, 4},
+			want:      []float64{nan, nan, 10},
+		},
+		{
+			name:      "rate without interval",
+			values:    []float64{1, 2},
+			positions: []float64{3, 3},
+			want:      []float64{nan, nan},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			differences(tt.values, tt.positions)
+			if !equalFloats(tt.values, tt.want) {
+				t.Errorf("got %v, want %v", tt.values, tt.want)
+			}
+		})
+	}
+}
+
+func TestPercentChange(t *testing.T) {
+	nan := math.NaN()
+
+	values := []float64{50, nan, 100, 0, 10}
+	percentChange(values)
+	if want := []float64{nan, nan, 100, -100, nan}; !equalFloats(values, want) {
+		t.Errorf("got %v, want %v", values, want)
+	}
+}
+
+func TestIndexTo100(t *testing.T) {
+	nan := math.NaN()
+
+	tests := []struct {
+		name    string
+		values  []float64
+		want    []float64
+		wantErr bool
+	}{
+		{
+			name:   "first value",
+			values: []float64{50, 75, 25},
+			want:   []float64{100, 150, 50},
+		},
+		{
+			name:   "leading zeros and gaps",
+			values: []float64{0, nan, 20, 30},
+			want:   []float64{0, nan, 100, 150},
+		},
+		{
+			name:   "negative base",
+			values: []float64{-4, -2},
+			want:   []float64{100, 50},
+		},
+		{
+			name:    "only zeros",
+			values:  []float64{0, nan, 0},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := indexTo100(tt.values)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("got %v, want an error", tt.values)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !equalFloats(tt.values, tt.want) {
+				t.Errorf("got %v, want %v", tt.values, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,376 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ainvaltin/nu-plugin"
+
+	"github.com/gtnebel/nu_plugin_nuplot/commands/flags"
+)
+
+// List of all available trend models, poly:N is a polynomial of degree N
+var TrendModels = []string{"linear", "poly:N", "exp", "log", "power"}
+
+// Milliseconds per day, dates are fitted in days since the first date.
+const msPerDay = 24 * 60 * 60 * 1000
+
+// A regression model that is fitted to the values of a series.
+type trendModel struct {
+	// One of linear, poly, exp, log or power
+	Kind string
+	// Degree of the polynomial, 1 for linear models
+	Degree int
+}
+
+// Parses the value of the --trend flag.
+func parseTrendModel(value string) (trendModel, error) {
+	kind, degree, isPoly := strings.Cut(value, ":")
+
+	switch {
+	case isPoly && kind == "poly":
+		n, err := strconv.Atoi(degree)
+		if err != nil || n < 1 || n > 9 {
+			return trendModel{}, fmt.Errorf("invalid degree %q of polynomial trend, expected 1 to 9", degree)
+		}
+		return trendModel{Kind: kind, Degree: n}, nil
+	case !isPoly && (kind == "linear" || kind == "exp" || kind == "log" || kind == "power"):
+		return trendModel{Kind: kind, Degree: 1}, nil
+	default:
+		return trendModel{}, fmt.Errorf("invalid trend %q, expected one of: %v", value, TrendModels)
+	}
+}
+
+// The result of fitting a [trendModel] to a series.
+type trendFit struct {
+	Model trendModel
+	// Coefficients of the model, see [trendFit.equation]
+	Coefficients []float64
+	// Coefficient of determination
+	R2 float64
+	// Number of data points the model was fitted to
+	Points int
+}
+
+// Returns the value of the fitted model at x.
+func (f trendFit) predict(x float64) float64 {
+	c := f.Coefficients
+
+	switch f.Model.Kind {
+	case "exp":
+		return c[0] * math.Exp(c[1]*x)
+	case "log":
+		return c[0] + c[1]*math.Log(x)
+	case "power":
+		return c[0] * math.Pow(x, c[1])
+	default:
+		// Horner's method for linear and polynomial models
+		y := 0.0
+		for i := len(c) - 1; i >= 0; i-- {
+			y = y*x + c[i]
+		}
+		return y
+	}
+}
+
+// Returns the equation of the fitted model, e.g. "y = 1.5 + 0.25x".
+func (f trendFit) equation() string {
+	c := f.Coefficients
+	num := func(v float64) string { return strconv.FormatFloat(v, 'g', 4, 64) }
+
+	switch f.Model.Kind {
+	case "exp":
+		return fmt.Sprintf("y = %s·e^(%sx)", num(c[0]), num(c[1]))
+	case "log":
+		return fmt.Sprintf("y = %s + %s·ln(x)", num(c[0]), num(c[1]))
+	case "power":
+		return fmt.Sprintf("y = %s·x^%s", num(c[0]), num(c[1]))
+	default:
+		terms := []string{num(c[0])}
+		for i := 1; i < len(c); i++ {
+			term := num(c[i]) + "x"
+			if i > 1 {
+				term += "^" + strconv.Itoa(i)
+			}
+			terms = append(terms, term)
+		}
+		return "y = " + strings.ReplaceAll(strings.Join(terms, " + "), "+ -", "- ")
+	}
+}
+
+// Fits the model to the points with least squares. Exponential, logarithmic
+// and power models are fitted as linear models of the logarithms.
+func fitTrend(model trendModel, x, y []float64) (trendFit, error) {
+	fit := trendFit{Model: model, Points: len(x)}
+	if len(x) <= model.Degree {
+		return fit, fmt.Errorf("%d points are not enough for a %s trend", len(x), model.Kind)
+	}
+
+	fx := make([]float64, len(x))
+	fy := make([]float64, len(y))
+	for i := range x {
+		fx[i], fy[i] = x[i], y[i]
+
+		if model.Kind == "log" || model.Kind == "power" {
+			if x[i] <= 0 {
+				return fit, fmt.Errorf("a %s trend needs positive x values, found %v", model.Kind, x[i])
+			}
+			fx[i] = math.Log(x[i])
+		}
+		if model.Kind == "exp" || model.Kind == "power" {
+			if y[i] <= 0 {
+				return fit, fmt.Errorf("a %s trend needs positive values, found %v", model.Kind, y[i])
+			}
+			fy[i] = math.Log(y[i])
+		}
+	}
+
+	c, err := polynomialFit(fx, fy, model.Degree)
+	if err != nil {
+		return fit, err
+	}
+	if model.Kind == "exp" || model.Kind == "power" {
+		c[0] = math.Exp(c[0])
+	}
+	fit.Coefficients = c
+
+	mean := 0.0
+	for _, v := range y {
+		mean += v
+	}
+	mean /= float64(len(y))
+
+	ssRes, ssTot := 0.0, 0.0
+	for i := range x {
+		ssRes += math.Pow(y[i]-fit.predict(x[i]), 2)
+		ssTot += math.Pow(y[i]-mean, 2)
+	}
+	fit.R2 = 1.0
+	if ssTot > 0 {
+		fit.R2 = 1 - ssRes/ssTot
+	}
+
+	return fit, nil
+}
+
+// Fits a polynomial of the given degree to the points with least squares and
+// returns its coefficients, starting with the constant term. The normal
+// equations are solved by Gaussian elimination. The x values are centered and
+// scaled to [-1, 1] before, otherwise the powers of large x values, e.g.
+// years, make the equations ill-conditioned. The coefficients are converted
+// back to the original x values.
+func polynomialFit(x, y []float64, degree int) ([]float64, error) {
+	n := degree + 1
+
+	center := 0.0
+	for _, v := range x {
+		center += v / float64(len(x))
+	}
+	scale := 0.0
+	for _, v := range x {
+		scale = max(scale, math.Abs(v-center))
+	}
+	if scale == 0 {
+		return nil, fmt.Errorf("the trend can not be fitted, the x values are not distinct enough")
+	}
+
+	// Augmented matrix of the normal equations
+	m := make([][]float64, n)
+	for i := range m {
+		m[i] = make([]float64, n+1)
+	}
+	for k := range x {
+		pow := make([]float64, 2*n)
+		pow[0] = 1
+		for i := 1; i < len(pow); i++ {
+			pow[i] = pow[i-1] * (x[k] - center) / scale
+		}
+		for i := range n {
+			for j := range n {
+				m[i][j] += pow[i+j]
+			}
+			m[i][n] += pow[i] * y[k]
+		}
+	}
+
+	for col := range n {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(m[row][col]) > math.Abs(m[pivot][col]) {
+				pivot = row
+			}
+		}
+		if m[pivot][col] == 0 {
+			return nil, fmt.Errorf("the trend can not be fitted, the x values are not distinct enough")
+		}
+		m[col], m[pivot] = m[pivot], m[col]
+
+		for row := range n {
+			if row == col {
+				continue
+			}
+			factor := m[row][col] / m[col][col]
+			for j := col; j <= n; j++ {
+				m[row][j] -= factor * m[col][j]
+			}
+		}
+	}
+
+	// The term a·((x-center)/scale)^k contributes
+	// a·binomial(k, j)·(-center)^(k-j)/scale^k to the coefficient of x^j.
+	res := make([]float64, n)
+	for k := range n {
+		a := m[k][n] / m[k][k] / math.Pow(scale, float64(k))
+		binomial := 1.0
+		for j := k; j >= 0; j-- {
+			res[j] += a * binomial * math.Pow(-center, float64(k-j))
+			binomial = binomial * float64(j) / float64(k-j+1)
+		}
+	}
+	return res, nil
+}
+
+// Returns the x values the trends are fitted to along with a description of
+// them. Dates are converted to days since the first date, rows without
+// numeric x values are numbered.
+func (t *numericTable) trendX() ([]float64, string) {
+	if positions := t.timePositions(); positions != nil {
+		x := make([]float64, len(positions))
+		for i, p := range positions {
+			x[i] = (p - positions[0]) / msPerDay
+		}
+		return x, "days since " + t.X.Dates[0].Format(time.RFC3339)
+	}
+
+	if positions := t.numericPositions(); positions != nil {
+		return positions, t.XAxisName
+	}
+
+	x := make([]float64, t.Rows)
+	for i := range x {
+		x[i] = float64(i)
+	}
+	return x, "row index"
+}
+
+// Fits the model given in the --trend flag to each of the given columns.
+// Missing values are left out.
+func (t *numericTable) fitTrends(call *nu.ExecCommand, names []string) (map[string]trendFit, error) {
+	model, err := parseTrendModel(getStringFlag(call, flags.Trend.Long, "linear"))
+	if err != nil {
+		return nil, flagError(call, flags.Trend, err)
+	}
+	x, _ := t.trendX()
+
+	fits := make(map[string]trendFit, len(names))
+	for _, name := range names {
+		px := make([]float64, 0, t.Rows)
+		py := make([]float64, 0, t.Rows)
+		for i, v := range t.Columns[name] {
+			if !math.IsNaN(v) {
+				px = append(px, x[i])
+				py = append(py, v)
+			}
+		}
+
+		fit, err := fitTrend(model, px, py)
+		if err != nil {
+			return nil, fmt.Errorf("series %q: %w", name, err)
+		}
+		slog.Debug("fitTrends", "series", name, "equation", fit.equation(), "r2", fit.R2)
+		fits[name] = fit
+	}
+
+	return fits, nil
+}
+
+// Adds a column with the fitted trend for each of the given columns, if the
+// --trend flag is given. The names of the new columns hold the equation and
+// R² of the fit, so that they are shown in the legend. They are returned by
+// the names of the columns they belong to.
+func (t *numericTable) addTrendColumns(call *nu.ExecCommand, names []string) (map[string]string, error) {
+	if getStringFlag(call, flags.Trend.Long, "") == "" {
+		return nil, nil
+	}
+
+	fits, err := t.fitTrends(call, names)
+	if err != nil {
+		return nil, err
+	}
+	x, _ := t.trendX()
+
+	res := make(map[string]string, len(names))
+	for _, name := range names {
+		fit := fits[name]
+		tName := fmt.Sprintf("%s trend: %s (R² = %.3f)", name, fit.equation(), fit.R2)
+
+		column := make([]float64, t.Rows)
+		for i := range column {
+			column[i] = fit.predict(x[i])
+		}
+		t.Columns[tName] = column
+		t.Kinds[tName] = t.Kinds[name]
+		res[name] = tName
+	}
+
+	return res, nil
+}
+
+// Returns the fitted trends of the line chart series as record instead of
+// plotting them. The record holds the model, the equation, the coefficients
+// and R² of each series.
+func returnLineTrends(ctx context.Context, input any, call *nu.ExecCommand) error {
+	if _, ok := input.([]facetGroup); ok {
+		return flagError(call, flags.Facet, fmt.Errorf("facets can not be combined with --%s", flags.TrendOnly.Long))
+	}
+	table, ok := input.(*numericTable)
+	if !ok {
+		return fmt.Errorf("returnLineTrends: unsupported input value type: %T", input)
+	}
+
+	seriesNames, err := table.selectColumns(call)
+	if err != nil {
+		return fmt.Errorf("returnLineTrends: %w", err)
+	}
+	if err := table.applyMissingPolicy(call, seriesNames); err != nil {
+		return fmt.Errorf("returnLineTrends: %w", err)
+	}
+	if err := table.applyTransform(call, seriesNames); err != nil {
+		return fmt.Errorf("returnLineTrends: %w", err)
+	}
+
+	fits, err := table.fitTrends(call, seriesNames)
+	if err != nil {
+		return err
+	}
+	_, xDesc := table.trendX()
+
+	res := make(nu.Record, len(fits))
+	for name, fit := range fits {
+		coefficients := make([]nu.Value, len(fit.Coefficients))
+		for i, c := range fit.Coefficients {
+			coefficients[i] = nu.Value{Value: c}
+		}
+
+		model := fit.Model.Kind
+		if model == "poly" {
+			model = fmt.Sprintf("poly:%d", fit.Model.Degree)
+		}
+
+		res[name] = nu.Value{Value: nu.Record{
+			"model":        nu.Value{Value: model},
+			"equation":     nu.Value{Value: fit.equation()},
+			"coefficients": nu.Value{Value: coefficients},
+			"r2":           nu.Value{Value: fit.R2},
+			"points":       nu.Value{Value: int64(fit.Points)},
+			"x":            nu.Value{Value: xDesc},
+		}}
+	}
+
+	return call.ReturnValue(ctx, nu.Value{Value: res})
+}
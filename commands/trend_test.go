@@ -0,0 +1,125 @@
+package commands
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFitTrend(t *testing.T) {
+	// Returns the x values from..to and the values of f at them.
+	points := func(from, to int, f func(x float64) float64) ([]float64, []float64) {
+		var x, y []float64
+		for i := from; i <= to; i++ {
+			x = append(x, float64(i))
+			y = append(y, f(float64(i)))
+		}
+		return x, y
+	}
+
+	tests := []struct {
+		name   string
+		model  trendModel
+		from   int
+		to     int
+		f      func(x float64) float64
+		coeffs []float64
+	}{
+		{
+			name:   "linear",
+			model:  trendModel{Kind: "linear", Degree: 1},
+			from:   0,
+			to:     10,
+			f:      func(x float64) float64 { return 1.5 + 0.25*x },
+			coeffs: []float64{1.5, 0.25},
+		},
+		{
+			name:   "quadratic",
+			model:  trendModel{Kind: "poly", Degree: 2},
+			from:   -5,
+			to:     5,
+			f:      func(x float64) float64 { return 3 - 2*x + 0.5*x*x },
+			coeffs: []float64{3, -2, 0.5},
+		},
+		{
+			name:  "cubic over years",
+			model: trendModel{Kind: "poly", Degree: 3},
+			from:  2000,
+			to:    2024,
+			f: func(x float64) float64 {
+				d := x - 2010
+				return 100 + 2*d - 0.3*d*d + 0.01*d*d*d
+			},
+		},
+		{
+			name:   "exp",
+			model:  trendModel{Kind: "exp", Degree: 1},
+			from:   0,
+			to:     10,
+			f:      func(x float64) float64 { return 2 * math.Exp(0.3*x) },
+			coeffs: []float64{2, 0.3},
+		},
+		{
+			name:   "log",
+			model:  trendModel{Kind: "log", Degree: 1},
+			from:   1,
+			to:     10,
+			f:      func(x float64) float64 { return 1 + 4*math.Log(x) },
+			coeffs: []float64{1, 4},
+		},
+		{
+			name:   "power",
+			model:  trendModel{Kind: "power", Degree: 1},
+			from:   1,
+			to:     10,
+			f:      func(x float64) float64 { return 3 * math.Pow(x, 1.5) },
+			coeffs: []float64{3, 1.5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x, y := points(tt.from, tt.to, tt.f)
+
+			fit, err := fitTrend(tt.model, x, y)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(fit.R2-1) > 1e-9 {
+				t.Errorf("got R² %v, want 1", fit.R2)
+			}
+			for i, want := range tt.coeffs {
+				if math.Abs(fit.Coefficients[i]-want) > 1e-9 {
+					t.Errorf("got coefficients %v, want %v", fit.Coefficients, tt.coeffs)
+					break
+				}
+			}
+			for i := range x {
+				if got := fit.predict(x[i]); math.Abs(got-y[i]) > 1e-6*math.Max(1, math.Abs(y[i])) {
+					t.Errorf("predict(%v) = %v, want %v", x[i], got, y[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFitTrendErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		model trendModel
+		x     []float64
+		y     []float64
+	}{
+		{"too few points", trendModel{Kind: "poly", Degree: 2}, []float64{1, 2}, []float64{1, 2}},
+		{"same x values", trendModel{Kind: "linear", Degree: 1}, []float64{3, 3, 3}, []float64{1, 2, 3}},
+		{"exp of negative values", trendModel{Kind: "exp", Degree: 1}, []float64{1, 2, 3}, []float64{1, -2, 3}},
+		{"log of zero", trendModel{Kind: "log", Degree: 1}, []float64{0, 1, 2}, []float64{1, 2, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if fit, err := fitTrend(tt.model, tt.x, tt.y); err == nil {
+				t.Errorf("got %+v, want an error", fit)
+			}
+		})
+	}
+}
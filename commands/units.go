@@ -0,0 +1,222 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+
+	"github.com/ainvaltin/nu-plugin"
+)
+
+// Kind of the values of a column. Values of all kinds are plotted as numbers,
+// but filesizes, durations and dates get labels in human readable units.
+type valueKind int
+
+const (
+	// Plain int or float values
+	kindNumber valueKind = iota
+	// Filesizes, plotted in bytes
+	kindFilesize
+	// Durations, plotted in nanoseconds
+	kindDuration
+	// Dates, plotted in milliseconds since epoch
+	kindDate
+)
+
+// Javascript function that formats a number of bytes with binary units. The
+// unit is chosen after rounding, so that 1048575 bytes are shown as 1 MiB and
+// not as 1024 KiB.
+const filesizeFormatter = `function (value) {
+	var units = ['B', 'KiB', 'MiB', 'GiB', 'TiB', 'PiB'];
+	var v = Math.abs(value);
+	var i = 0;
+	while (+v.toFixed(2) >= 1024 && i < units.length - 1) {
+		v = v / 1024;
+		i++;
+	}
+	return (value < 0 ? '-' : '') + (+v.toFixed(2)) + ' ' + units[i];
+}`
+
+// Javascript function that formats a number of nanoseconds with the largest
+// fitting time unit. Like for filesizes, the next unit is used, if the value
+// reaches it after rounding.
+const durationFormatter = `function (value) {
+	var units = [['ns', 1], ['µs', 1e3], ['ms', 1e6], ['sec', 1e9], ['min', 6e10], ['hr', 3.6e12], ['day', 8.64e13]];
+	var v = Math.abs(value);
+	var i = 0;
+	while (i < units.length - 1 && +(v / units[i][1]).toFixed(2) * units[i][1] >= units[i + 1][1]) {
+		i++;
+	}
+	return (+(value / units[i][1]).toFixed(2)) + units[i][0];
+}`
+
+// Javascript function that formats milliseconds since epoch as date.
+const dateFormatter = `function (value) {
+	return echarts.time.format(value, '{yyyy}-{MM}-{dd} {HH}:{mm}:{ss}', false);
+}`
+
+// Converts a nushell value to float64 and returns the kind of the value.
+// Filesizes are converted to bytes, durations to nanoseconds and dates to
+// milliseconds since epoch.
+func valueToFloat64Kind(value nu.Value) (float64, valueKind, error) {
+	switch v := value.Value.(type) {
+	case int64:
+		return float64(v), kindNumber, nil
+	case float64:
+		return v, kindNumber, nil
+	case nu.Filesize:
+		return float64(v), kindFilesize, nil
+	case time.Duration:
+		return float64(v), kindDuration, nil
+	case time.Time:
+		return float64(v.UnixMilli()), kindDate, nil
+	default:
+		return 0, kindNumber, fmt.Errorf("incompatible input type for valueToFloat64Kind(): %T", v)
+	}
+}
+
+// Converts a float64 back to a nushell value of the given kind.
+func float64ToValue(value float64, kind valueKind) nu.Value {
+	switch kind {
+	case kindFilesize:
+		return nu.Value{Value: nu.Filesize(value)}
+	case kindDuration:
+		return nu.Value{Value: time.Duration(value)}
+	case kindDate:
+		return nu.Value{Value: time.UnixMilli(int64(value))}
+	default:
+		return nu.Value{Value: value}
+	}
+}
+
+// Returns the javascript formatter for values of the given kind or an empty
+// string for plain numbers.
+func (k valueKind) formatter() string {
+	switch k {
+	case kindFilesize:
+		return filesizeFormatter
+	case kindDuration:
+		return durationFormatter
+	case kindDate:
+		return dateFormatter
+	default:
+		return ""
+	}
+}
+
+// Returns the kind that all given columns share or [kindNumber], if the kinds
+// differ.
+func commonKind(kinds map[string]valueKind, names []string) valueKind {
+	if len(names) == 0 {
+		return kindNumber
+	}
+
+	kind := kinds[names[0]]
+	for _, name := range names[1:] {
+		if kinds[name] != kind {
+			return kindNumber
+		}
+	}
+	return kind
+}
+
+// Returns a global option that changes the first y-axis with the given
+// function. Other than [charts.WithYAxisOpts] it keeps all settings of the
+// axis that are not changed.
+func withYAxisChange(change func(yAxis *opts.YAxis)) charts.GlobalOpts {
+	return func(bc *charts.BaseConfiguration) {
+		if len(bc.YAxisList) > 0 {
+			change(&bc.YAxisList[0])
+		}
+	}
+}
+
+// Returns a global option that changes the first x-axis with the given
+// function. Other than [charts.WithXAxisOpts] it keeps all settings of the
+// axis that are not changed.
+func withXAxisChange(change func(xAxis *opts.XAxis)) charts.GlobalOpts {
+	return func(bc *charts.BaseConfiguration) {
+		if len(bc.XAxisList) > 0 {
+			change(&bc.XAxisList[0])
+		}
+	}
+}
+
+// Returns a global option that formats the labels of the value axis for
+// values of the given kind. The value axis is the x-axis, if the axes of the
+// chart are reversed.
+func withValueAxisKind(kind valueKind, reversed bool) charts.GlobalOpts {
+	formatter := kind.formatter()
+
+	setFormatter := func(label *opts.AxisLabel) *opts.AxisLabel {
+		if label == nil {
+			label = &opts.AxisLabel{}
+		}
+		label.Formatter = opts.FuncOpts(formatter)
+		return label
+	}
+
+	return func(bc *charts.BaseConfiguration) {
+		switch {
+		case formatter == "":
+		case reversed:
+			withXAxisChange(func(xAxis *opts.XAxis) { xAxis.AxisLabel = setFormatter(xAxis.AxisLabel) })(bc)
+		default:
+			withYAxisChange(func(yAxis *opts.YAxis) { yAxis.AxisLabel = setFormatter(yAxis.AxisLabel) })(bc)
+		}
+	}
+}
+
+// Returns the series options that format the tooltip of a series for values
+// of the given kind.
+func seriesKindOpts(kind valueKind) []charts.SeriesOpts {
+	formatter := kind.formatter()
+	if formatter == "" {
+		return nil
+	}
+
+	return []charts.SeriesOpts{
+		charts.WithSeriesTooltipOpts(opts.SeriesTooltip{
+			ValueFormatter: opts.FuncOpts(formatter),
+		}),
+	}
+}
+
+// Returns the kind that all values of the given columns in the input share or
+// [kindNumber], if the kinds differ. Plain values of a list are treated as
+// values of the [DefaultSeries] column. Lists of tables are searched
+// recursively.
+func detectValueKind(input []nu.Value, names []string) valueKind {
+	kinds := make(map[valueKind]bool)
+
+	var detect func(list []nu.Value)
+	detect = func(list []nu.Value) {
+		for _, item := range list {
+			switch itemValue := item.Value.(type) {
+			case nu.Record:
+				for _, name := range names {
+					if _, kind, err := valueToFloat64Kind(itemValue[name]); err == nil {
+						kinds[kind] = true
+					}
+				}
+			case []nu.Value:
+				detect(itemValue)
+			default:
+				if _, kind, err := valueToFloat64Kind(item); err == nil {
+					kinds[kind] = true
+				}
+			}
+		}
+	}
+	detect(input)
+
+	if len(kinds) != 1 {
+		return kindNumber
+	}
+	for kind := range kinds {
+		return kind
+	}
+	return kindNumber
+}
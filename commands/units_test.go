@@ -0,0 +1,175 @@
+package commands
+
+import (
+	"encoding/json"
+	"math"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+
+	"github.com/ainvaltin/nu-plugin"
+)
+
+func TestValueToFloat64(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   any
+		want    float64
+		wantErr bool
+	}{
+		{"int", int64(-3), -3, false},
+		{"float", 0.5, 0.5, false},
+		{"zero filesize", nu.Filesize(0), 0, false},
+		{"filesize below 1 KiB", nu.Filesize(1023), 1023, false},
+		{"filesize of 1 KiB", nu.Filesize(1024), 1024, false},
+		{"filesize of 1 MiB", nu.Filesize(1 << 20), 1 << 20, false},
+		{"negative filesize", nu.Filesize(-1024), -1024, false},
+		{"nanosecond", time.Nanosecond, 1, false},
+		{"duration below 1 ms", time.Millisecond - 1, 999999, false},
+		{"duration of 1 s", time.Second, 1e9, false},
+		{"negative duration", -1500 * time.Millisecond, -1.5e9, false},
+		{"date", time.Unix(0, 0), 0, true},
+		{"string", "1024", 0, true},
+		{"nothing", nil, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ValueToFloat64(nu.Value{Value: tt.value})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValueToFloat64Kind(t *testing.T) {
+	date := time.Date(2024, 6, 1, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		value    any
+		want     float64
+		wantKind valueKind
+	}{
+		{"int", int64(7), 7, kindNumber},
+		{"float", 1.25, 1.25, kindNumber},
+		{"filesize", nu.Filesize(1536), 1536, kindFilesize},
+		{"duration", 2 * time.Millisecond, 2e6, kindDuration},
+		{"date", date, float64(date.UnixMilli()), kindDate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, kind, err := valueToFloat64Kind(nu.Value{Value: tt.value})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want || kind != tt.wantKind {
+				t.Errorf("got %v of kind %v, want %v of kind %v", got, kind, tt.want, tt.wantKind)
+			}
+
+			// Filesizes, durations and dates keep their kind, see
+			// [aggregateValues].
+			if tt.wantKind != kindNumber {
+				if back := float64ToValue(got, kind); !equalValues(back.Value, tt.value) {
+					t.Errorf("got %v back, want %v", back.Value, tt.value)
+				}
+			}
+		})
+	}
+}
+
+// Compares values, dates are equal if they denote the same instant.
+func equalValues(a, b any) bool {
+	if date, ok := a.(time.Time); ok {
+		other, ok := b.(time.Time)
+		return ok && date.Equal(other)
+	}
+	return a == b
+}
+
+func TestSeriesKindOpts(t *testing.T) {
+	tests := []struct {
+		kind valueKind
+		want string
+	}{
+		{kindNumber, ""},
+		{kindFilesize, filesizeFormatter},
+		{kindDuration, durationFormatter},
+		{kindDate, dateFormatter},
+	}
+
+	for _, tt := range tests {
+		series := &charts.SingleSeries{}
+		for _, opt := range seriesKindOpts(tt.kind) {
+			opt(series)
+		}
+
+		switch {
+		case tt.want == "" && series.SeriesTooltip != nil:
+			t.Errorf("kind %v: got tooltip %+v, want none", tt.kind, series.SeriesTooltip)
+		case tt.want != "" && (series.SeriesTooltip == nil || series.SeriesTooltip.ValueFormatter != opts.FuncOpts(tt.want)):
+			t.Errorf("kind %v: got tooltip %+v, want a value formatter", tt.kind, series.SeriesTooltip)
+		}
+	}
+}
+
+// Runs the tooltip formatter of the given kind with node and returns the
+// labels of the values.
+func formatWithNode(t *testing.T, kind valueKind, values []float64) []string {
+	t.Helper()
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node is needed to run the formatter scripts")
+	}
+
+	series := &charts.SingleSeries{}
+	for _, opt := range seriesKindOpts(kind) {
+		opt(series)
+	}
+	// The functions are marked to be inserted as code into the options.
+	formatter := strings.ReplaceAll(string(series.SeriesTooltip.ValueFormatter), "__f__", "")
+
+	input, err := json.Marshal(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	script := "console.log(JSON.stringify(" + string(input) + ".map(" + formatter + ")));"
+
+	out, err := exec.Command(node, "-e", script).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var labels []string
+	if err := json.Unmarshal(out, &labels); err != nil {
+		t.Fatal(err)
+	}
+	return labels
+}
+
+func TestFilesizeFormatter(t *testing.T) {
+	values := []float64{0, 1, 1023, 1024, 1536, 1048575, 1 << 20, 1.5 * (1 << 30), 1 << 40, -2048, math.Pow(1024, 6)}
+	want := []string{"0 B", "1 B", "1023 B", "1 KiB", "1.5 KiB", "1 MiB", "1 MiB", "1.5 GiB", "1 TiB", "-2 KiB", "1024 PiB"}
+
+	if got := formatWithNode(t, kindFilesize, values); !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestDurationFormatter(t *testing.T) {
+	values := []float64{0, 999, 1000, 999999, 1e6, 1.5e6, 999e6, 1e9, 59.999e9, 6e10, 3.6e12, 8.64e13, -2e6}
+	want := []string{"0ns", "999ns", "1µs", "1ms", "1ms", "1.5ms", "999ms", "1sec", "1min", "1min", "1hr", "1day", "-2ms"}
+
+	if got := formatWithNode(t, kindDuration, values); !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,254 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"slices"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+
+	"github.com/ainvaltin/nu-plugin"
+	"github.com/ainvaltin/nu-plugin/types"
+
+	"github.com/gtnebel/nu_plugin_nuplot/commands/flags"
+)
+
+// Colors of the waterfall bars.
+const (
+	waterfallIncreaseColor = "#91cc75"
+	waterfallDecreaseColor = "#ee6666"
+	waterfallTotalColor    = "#5470c6"
+)
+
+// A single labeled step of a waterfall chart.
+type waterfallStep struct {
+	Label string
+	Delta float64
+}
+
+// This function initializes the nuplot waterfall command.
+func NuplotWaterfall() *nu.Command {
+	return &nu.Command{
+		Signature: nu.PluginSignature{
+			Name:        "nuplot waterfall",
+			Category:    "Chart",
+			Desc:        "Plots a waterfall chart of labeled deltas.",
+			Description: "Each row is drawn as floating bar from the running total before to the running total after the step. Positive and negative steps are colored differently. The labels are taken from the --xaxis column and the deltas from the --value column. Subtotal and total bars can be added. The bars are stacked on a transparent base that starts at zero, so there are no logarithmic axes.",
+			SearchTerms: []string{"plot", "graph", "bar", "waterfall", "bridge"},
+			Named: []nu.Flag{
+				flags.XAxis,
+				flags.Value,
+				flags.Subtotal,
+				flags.Total,
+				flags.XName,
+				flags.YName,
+				flags.XMin,
+				flags.XMax,
+				flags.YMin,
+				flags.YMax,
+				flags.Title,
+				flags.SubTitle,
+				flags.Width,
+				flags.Height,
+				flags.ColorTheme,
+				flags.Verbose,
+			},
+			InputOutputTypes: []nu.InOutTypes{
+				{In: types.Table(types.RecordDef{}), Out: types.Nothing()},
+				{In: types.String(), Out: types.Nothing()},
+				{In: types.Binary(), Out: types.Nothing()},
+				{In: types.List(types.Number()), Out: types.Nothing()},
+			},
+			AllowMissingExamples: true,
+		},
+		Examples: []nu.Example{
+			{
+				Description: `Plot budget changes with a subtotal and a total bar.`,
+				Example:     `[[item change]; [Start 100] [Sales 40] [Refunds -15] [Salaries -60] [Grants 25]] | nuplot waterfall --xaxis item --value change --subtotal Refunds --total`,
+			},
+		},
+		OnRun: nuplotWaterfallHandler,
+	}
+}
+
+func nuplotWaterfallHandler(ctx context.Context, call *nu.ExecCommand) error {
+	checkVerboseFlag(call)
+	return handleCommandInput(call, plotWaterfall)
+}
+
+// Reads the steps of the waterfall from the input list. If no value column is
+// given, the table must have exactly one numeric column besides the labels.
+func waterfallReadInput(input []nu.Value, xAxisName string, valueName string) ([]waterfallStep, error) {
+	steps := make([]waterfallStep, 0, len(input))
+
+	for itemIndex, item := range input {
+		step := waterfallStep{Label: fmt.Sprint(itemIndex)}
+
+		switch itemValue := item.Value.(type) {
+		case int64, float64:
+			step.Delta, _ = ValueToFloat64(item)
+		case nu.Record:
+			// Try to set xAxisName to one of the columns in the record.
+			if itemIndex == 0 {
+				xAxisName = autoSetXaxis(itemValue, xAxisName)
+			}
+
+			if v, ok := itemValue[xAxisName]; ok {
+				step.Label = fmt.Sprint(v.Value)
+			}
+
+			if valueName == "" {
+				for k, v := range itemValue {
+					if _, err := ValueToFloat64(v); k == xAxisName || err != nil {
+						continue
+					}
+					if valueName != "" {
+						return nil, fmt.Errorf("waterfallReadInput: found more than one value column (%q and %q), use --value to select one", valueName, k)
+					}
+					valueName = k
+				}
+			}
+
+			delta, err := ValueToFloat64(itemValue[valueName])
+			if err != nil {
+				return nil, fmt.Errorf("waterfallReadInput: column %q in row %d: %w", valueName, itemIndex, err)
+			}
+			step.Delta = delta
+		default:
+			return nil, fmt.Errorf("waterfallReadInput: unsupported input value type: %T", itemValue)
+		}
+
+		steps = append(steps, step)
+	}
+
+	return steps, nil
+}
+
+// Builds the floating bar for a step from a to b. The base series holds the
+// invisible part below the bar. If the bar crosses zero, the negative part is
+// put into the base series and colored like the bar, because echarts stacks
+// positive and negative values separately.
+func waterfallBar(a, b float64, color string) (base opts.BarData, bar opts.BarData) {
+	low, high := math.Min(a, b), math.Max(a, b)
+
+	switch {
+	case low >= 0:
+		return opts.BarData{Value: low}, opts.BarData{Value: high - low}
+	case high <= 0:
+		return opts.BarData{Value: high}, opts.BarData{Value: low - high}
+	default:
+		return opts.BarData{Value: low, ItemStyle: &opts.ItemStyle{Color: color}},
+			opts.BarData{Value: high}
+	}
+}
+
+// Computes the bars of the waterfall from the steps. Each step is drawn from
+// the running total before to the running total after it. Subtotal bars are
+// added after the steps with the given labels and a total bar at the end, if
+// total is true. The labels of the bars are returned along with the
+// transparent base series and the visible series by name, see [waterfallBar].
+func waterfallSeries(steps []waterfallStep, subtotals []string, total bool) ([]string, BarDataList, map[string]BarDataList) {
+	labels := make([]string, 0, len(steps))
+	base := make(BarDataList, 0, len(steps))
+	series := map[string]BarDataList{
+		"Increase": make(BarDataList, 0, len(steps)),
+		"Decrease": make(BarDataList, 0, len(steps)),
+		"Total":    make(BarDataList, 0, len(steps)),
+	}
+	empty := opts.BarData{Value: "-"}
+
+	// Appends a bar to the series with the given name and an empty value to
+	// all other series.
+	addBar := func(label string, name string, baseValue opts.BarData, bar opts.BarData) {
+		labels = append(labels, label)
+		base = append(base, baseValue)
+		for sName := range series {
+			if sName == name {
+				series[sName] = append(series[sName], bar)
+			} else {
+				series[sName] = append(series[sName], empty)
+			}
+		}
+	}
+
+	runningTotal := 0.0
+	for _, step := range steps {
+		name, color := "Increase", waterfallIncreaseColor
+		if step.Delta < 0 {
+			name, color = "Decrease", waterfallDecreaseColor
+		}
+
+		baseValue, bar := waterfallBar(runningTotal, runningTotal+step.Delta, color)
+		addBar(step.Label, name, baseValue, bar)
+		runningTotal += step.Delta
+
+		if slices.Contains(subtotals, step.Label) {
+			baseValue, bar := waterfallBar(0, runningTotal, waterfallTotalColor)
+			addBar("Subtotal "+step.Label, "Total", baseValue, bar)
+		}
+	}
+
+	if total {
+		baseValue, bar := waterfallBar(0, runningTotal, waterfallTotalColor)
+		addBar("Total", "Total", baseValue, bar)
+	}
+
+	return labels, base, series
+}
+
+func plotWaterfall(input any, call *nu.ExecCommand) error {
+	xAxisName := getCellPathFlag(call, "xaxis", XAxisSeries)
+	valueName := getCellPathFlag(call, flags.Value.Long, "")
+	subtotals := getStringListFlag(call, flags.Subtotal.Long)
+	total := getBoolFlag(call, flags.Total.Long)
+	slog.Debug("plotWaterfall", "xAxisName", xAxisName, "valueName", valueName, "subtotals", subtotals, "total", total)
+
+	var steps []waterfallStep
+	switch inputValue := input.(type) {
+	case []nu.Value:
+		var err error
+		if steps, err = waterfallReadInput(inputValue, xAxisName, valueName); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("plotWaterfall: unsupported input value type: %T", inputValue)
+	}
+
+	labels, base, series := waterfallSeries(steps, subtotals, total)
+
+	// create a new bar instance
+	bar := charts.NewBar()
+
+	bar.SetGlobalOptions(buildGlobalChartOptions(call)...)
+
+	axisOpts, err := buildAxisOptions(call)
+	if err != nil {
+		return err
+	}
+	bar.SetGlobalOptions(axisOpts...)
+	bar.SetGlobalOptions(charts.WithLegendOpts(opts.Legend{
+		Data: []string{"Increase", "Decrease", "Total"},
+	}))
+
+	stack := charts.WithBarChartOpts(opts.BarChart{Stack: "waterfall"})
+
+	// The base series is transparent and only lifts the visible bars to the
+	// running total.
+	bar.AddSeries("Base", base, stack, charts.WithItemStyleOpts(opts.ItemStyle{
+		Color: "transparent",
+	}))
+	bar.AddSeries("Increase", series["Increase"], stack, charts.WithItemStyleOpts(opts.ItemStyle{Color: waterfallIncreaseColor}))
+	bar.AddSeries("Decrease", series["Decrease"], stack, charts.WithItemStyleOpts(opts.ItemStyle{Color: waterfallDecreaseColor}))
+	bar.AddSeries("Total", series["Total"], stack, charts.WithItemStyleOpts(opts.ItemStyle{Color: waterfallTotalColor}))
+	slog.Debug("plotWaterfall: Added steps", "steps", len(steps), "bars", len(labels))
+
+	bar = bar.SetXAxis(labels)
+
+	setPageTitle(call, &bar.BaseConfiguration)
+
+	return renderChart(func(f *os.File) error { return bar.Render(f) })
+}
@@ -0,0 +1,147 @@
+package commands
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/ainvaltin/nu-plugin"
+)
+
+func TestWaterfallReadInput(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     []nu.Value
+		valueName string
+		want      []waterfallStep
+		wantErr   bool
+	}{
+		{
+			name:  "list of numbers",
+			input: nuValues(int64(10), -2.5),
+			want:  []waterfallStep{{"0", 10}, {"1", -2.5}},
+		},
+		{
+			name: "single value column",
+			input: records(
+				map[string]any{"item": "Start", "change": int64(100)},
+				map[string]any{"item": "Refunds", "change": int64(-15)},
+			),
+			want: []waterfallStep{{"Start", 100}, {"Refunds", -15}},
+		},
+		{
+			name: "selected value column",
+			input: records(
+				map[string]any{"item": "Start", "change": int64(100), "budget": int64(90)},
+			),
+			valueName: "budget",
+			want:      []waterfallStep{{"Start", 90}},
+		},
+		{
+			name: "ambiguous value columns",
+			input: records(
+				map[string]any{"item": "Start", "change": int64(100), "budget": int64(90)},
+			),
+			wantErr: true,
+		},
+		{
+			name: "missing value",
+			input: records(
+				map[string]any{"item": "Start", "change": int64(100)},
+				map[string]any{"item": "Refunds", "change": "n/a"},
+			),
+			valueName: "change",
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := waterfallReadInput(tt.input, "item", tt.valueName)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWaterfallSeries(t *testing.T) {
+	// A bar of the chart: the series it belongs to, the transparent base and
+	// the visible part on top of it.
+	type bar struct {
+		Label  string
+		Series string
+		Base   any
+		Value  any
+	}
+
+	tests := []struct {
+		name      string
+		steps     []waterfallStep
+		subtotals []string
+		total     bool
+		want      []bar
+	}{
+		{
+			name:      "running totals with subtotal and total",
+			steps:     []waterfallStep{{"Start", 100}, {"Sales", 40}, {"Refunds", -15}, {"Salaries", -60}},
+			subtotals: []string{"Refunds"},
+			total:     true,
+			want: []bar{
+				{"Start", "Increase", 0.0, 100.0},
+				{"Sales", "Increase", 100.0, 40.0},
+				{"Refunds", "Decrease", 125.0, 15.0},
+				{"Subtotal Refunds", "Total", 0.0, 125.0},
+				{"Salaries", "Decrease", 65.0, 60.0},
+				{"Total", "Total", 0.0, 65.0},
+			},
+		},
+		{
+			name:  "below zero",
+			steps: []waterfallStep{{"a", -10}, {"b", -5}, {"c", 3}},
+			total: true,
+			want: []bar{
+				{"a", "Decrease", 0.0, -10.0},
+				{"b", "Decrease", -10.0, -5.0},
+				{"c", "Increase", -12.0, -3.0},
+				{"Total", "Total", 0.0, -12.0},
+			},
+		},
+		{
+			// echarts stacks negative values separately, so the negative
+			// part of the bar is drawn by the base series.
+			name:  "crossing zero",
+			steps: []waterfallStep{{"a", 10}, {"b", -30}},
+			want: []bar{
+				{"a", "Increase", 0.0, 10.0},
+				{"b", "Decrease", -20.0, 10.0},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			labels, base, series := waterfallSeries(tt.steps, tt.subtotals, tt.total)
+
+			got := make([]bar, len(labels))
+			for i, label := range labels {
+				got[i] = bar{Label: label, Base: base[i].Value}
+				for name, data := range series {
+					if data[i].Value == "-" {
+						continue
+					}
+					if got[i].Series != "" {
+						t.Fatalf("got bar %q in series %q and %q", label, got[i].Series, name)
+					}
+					got[i].Series, got[i].Value = name, data[i].Value
+				}
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -44,8 +44,13 @@ func main() {
 			commands.NuplotLine(),
 			commands.NuplotKline(),
 			commands.NuplotBar(),
+			commands.NuplotScatter(),
 			commands.NuplotPie(),
 			commands.NuplotBoxPlot(),
+			commands.NuplotCombo(),
+			commands.NuplotWaterfall(),
+			commands.NuplotGantt(),
+			commands.NuplotDecompose(),
 		},
 		PluginVersion,
 		nil,